@@ -0,0 +1,242 @@
+// Package capabilities works out which CloudFormation capabilities
+// are needed to deploy a cfn.Template
+package capabilities
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+const (
+	IAM        = "CAPABILITY_IAM"
+	NamedIAM   = "CAPABILITY_NAMED_IAM"
+	AutoExpand = "CAPABILITY_AUTO_EXPAND"
+)
+
+// Capability is a capability required by a template
+// along with the parts of the template that require it
+type Capability struct {
+	Name    string
+	Reasons []string
+}
+
+// Required returns the capabilities that are required to deploy the template
+// in the order IAM, NamedIAM, AutoExpand.
+// The templates of nested stacks are read relative to the current directory;
+// use RequiredIn for templates that live elsewhere.
+func Required(t cfn.Template) []Capability {
+	return RequiredIn(t, ".")
+}
+
+// RequiredIn is like Required but reads the local templates of nested stacks relative to baseDir
+// and includes the capabilities that they require.
+// Nested stacks whose templates are remote are assumed to require every capability.
+func RequiredIn(t cfn.Template, baseDir string) []Capability {
+	reasons := required(t, baseDir, nil)
+
+	out := make([]Capability, 0)
+	for _, name := range []string{IAM, NamedIAM, AutoExpand} {
+		if len(reasons[name]) > 0 {
+			out = append(out, Capability{name, reasons[name]})
+		}
+	}
+
+	return out
+}
+
+// required returns the reasons for each capability that t requires.
+// parents lists the paths of the templates that t is nested within so that cycles can be skipped.
+func required(t cfn.Template, baseDir string, parents []string) map[string][]string {
+	reasons := make(map[string][]string)
+
+	resources, _ := t["Resources"].(map[string]interface{})
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		resource, ok := resources[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		typeName, _ := resource["Type"].(string)
+		props, _ := resource["Properties"].(map[string]interface{})
+
+		switch {
+		case typeName == "AWS::CloudFormation::Stack", typeName == "AWS::Serverless::Application":
+			// Nested stacks need every capability that their templates need
+			for capability, nestedReasons := range nested(name, typeName, props, baseDir, parents) {
+				reasons[capability] = append(reasons[capability], nestedReasons...)
+			}
+		case strings.HasPrefix(typeName, "AWS::IAM::"):
+			reasons[IAM] = append(reasons[IAM], name)
+
+			if nameProp := iamNameProperty(typeName); nameProp != "" {
+				if _, ok := props[nameProp]; ok {
+					reasons[NamedIAM] = append(reasons[NamedIAM], fmt.Sprintf("%s (%s)", name, nameProp))
+				}
+			}
+		case typeName == "AWS::Serverless::Function", typeName == "AWS::Serverless::StateMachine":
+			// SAM creates a role unless one is provided
+			if _, ok := props["Role"]; !ok {
+				reasons[IAM] = append(reasons[IAM], fmt.Sprintf("%s (creates a role)", name))
+			}
+		}
+	}
+
+	for _, transform := range transforms(t) {
+		reasons[AutoExpand] = append(reasons[AutoExpand], transform)
+	}
+
+	return reasons
+}
+
+// nested returns the reasons for each capability that the nested stack called name requires.
+// Local templates are read and checked; any other template might require every capability.
+func nested(name, typeName string, props map[string]interface{}, baseDir string, parents []string) map[string][]string {
+	prop := "TemplateURL"
+	if typeName == "AWS::Serverless::Application" {
+		prop = "Location"
+	}
+
+	everything := func(why string) map[string][]string {
+		return map[string][]string{
+			IAM:        {fmt.Sprintf("%s (%s may contain IAM resources)", name, why)},
+			NamedIAM:   {fmt.Sprintf("%s (%s may contain named IAM resources)", name, why)},
+			AutoExpand: {fmt.Sprintf("%s (%s may contain macros)", name, why)},
+		}
+	}
+
+	location, ok := props[prop].(string)
+	if !ok || !isLocal(location) {
+		return everything("remote nested template")
+	}
+
+	path := location
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	for _, parent := range parents {
+		if parent == path {
+			// Packaging will refuse the cycle
+			return nil
+		}
+	}
+
+	template, err := parse.File(path)
+	if err != nil {
+		return everything("unreadable nested template")
+	}
+
+	reasons := required(template, filepath.Dir(path), append(parents, path))
+	for capability, nestedReasons := range reasons {
+		for i, reason := range nestedReasons {
+			nestedReasons[i] = name + "/" + reason
+		}
+		reasons[capability] = nestedReasons
+	}
+
+	return reasons
+}
+
+// isLocal returns true if location looks like a local file path rather than a URL
+func isLocal(location string) bool {
+	if location == "" {
+		return false
+	}
+
+	for _, prefix := range []string{"s3://", "http://", "https://"} {
+		if strings.HasPrefix(strings.ToLower(location), prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Names returns the names of the capabilities
+func Names(capabilities []Capability) []string {
+	out := make([]string, len(capabilities))
+	for i, c := range capabilities {
+		out[i] = c.Name
+	}
+
+	return out
+}
+
+func iamNameProperty(typeName string) string {
+	switch typeName {
+	case "AWS::IAM::Group":
+		return "GroupName"
+	case "AWS::IAM::InstanceProfile":
+		return "InstanceProfileName"
+	case "AWS::IAM::ManagedPolicy":
+		return "ManagedPolicyName"
+	case "AWS::IAM::Role":
+		return "RoleName"
+	case "AWS::IAM::User":
+		return "UserName"
+	}
+
+	return ""
+}
+
+// transforms returns a description of each transform or macro used by the template
+func transforms(t cfn.Template) []string {
+	out := make([]string, 0)
+
+	switch v := t["Transform"].(type) {
+	case string:
+		out = append(out, "Transform: "+v)
+	case []interface{}:
+		for _, name := range v {
+			out = append(out, fmt.Sprintf("Transform: %v", name))
+		}
+	}
+
+	var find func(interface{}, []string)
+	find = func(data interface{}, path []string) {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if key == "Fn::Transform" {
+					name := "?"
+					if m, ok := v[key].(map[string]interface{}); ok {
+						name = fmt.Sprint(m["Name"])
+					}
+					out = append(out, fmt.Sprintf("%s (Fn::Transform: %s)", strings.Join(path, "/"), name))
+					continue
+				}
+
+				find(v[key], append(path, key))
+			}
+		case []interface{}:
+			for i, child := range v {
+				find(child, append(path, fmt.Sprint(i)))
+			}
+		}
+	}
+
+	for _, section := range []string{"Resources", "Outputs", "Mappings", "Conditions", "Parameters"} {
+		if data, ok := t[section]; ok {
+			find(data, []string{section})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,140 @@
+package capabilities_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+func TestRequired(t *testing.T) {
+	cases := []string{
+		`
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`,
+		`
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+  Named:
+    Type: AWS::IAM::User
+    Properties:
+      UserName: bob
+`,
+		`
+Transform: AWS::Serverless-2016-10-31
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      Fn::Transform:
+        Name: AWS::Include
+`,
+		`
+Resources:
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: https://example.com/child.yaml
+`,
+	}
+
+	expecteds := [][]capabilities.Capability{
+		{},
+		{
+			{Name: "CAPABILITY_IAM", Reasons: []string{"Named", "Role"}},
+			{Name: "CAPABILITY_NAMED_IAM", Reasons: []string{"Named (UserName)"}},
+		},
+		{
+			{Name: "CAPABILITY_IAM", Reasons: []string{"Function (creates a role)"}},
+			{Name: "CAPABILITY_AUTO_EXPAND", Reasons: []string{
+				"Transform: AWS::Serverless-2016-10-31",
+				"Resources/Bucket/Properties (Fn::Transform: AWS::Include)",
+			}},
+		},
+		{
+			{Name: "CAPABILITY_IAM", Reasons: []string{"Child (remote nested template may contain IAM resources)"}},
+			{Name: "CAPABILITY_NAMED_IAM", Reasons: []string{"Child (remote nested template may contain named IAM resources)"}},
+			{Name: "CAPABILITY_AUTO_EXPAND", Reasons: []string{"Child (remote nested template may contain macros)"}},
+		},
+	}
+
+	for i, testCase := range cases {
+		template, err := parse.String(testCase)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := capabilities.Required(template)
+
+		if !reflect.DeepEqual(actual, expecteds[i]) {
+			t.Errorf("Got %#v, want %#v", actual, expecteds[i])
+		}
+	}
+}
+
+func TestRequiredInLocalNestedStack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capabilities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"child.yaml": `
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+  Grandchild:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: grandchild.yaml
+`,
+		"grandchild.yaml": `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	template, err := parse.String(`
+Resources:
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: child.yaml
+  Missing:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: missing.yaml
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []capabilities.Capability{
+		{Name: "CAPABILITY_IAM", Reasons: []string{"Child/Role", "Missing (unreadable nested template may contain IAM resources)"}},
+		{Name: "CAPABILITY_NAMED_IAM", Reasons: []string{"Missing (unreadable nested template may contain named IAM resources)"}},
+		{Name: "CAPABILITY_AUTO_EXPAND", Reasons: []string{"Missing (unreadable nested template may contain macros)"}},
+	}
+
+	actual := capabilities.RequiredIn(template, dir)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %#v, want %#v", actual, expected)
+	}
+}
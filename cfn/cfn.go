@@ -34,6 +34,31 @@ func (t Template) Map() map[string]interface{} {
 	return map[string]interface{}(t)
 }
 
+// Copy returns a deep copy of the template
+// that can be modified without affecting the original
+func (t Template) Copy() Template {
+	return Template(copyValue(t.Map()).(map[string]interface{}))
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = copyValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = copyValue(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // Diff returns a Diff object representing the difference
 // between this template and the template passed to Diff
 func (t Template) Diff(other Template) diff.Diff {
@@ -47,3 +47,34 @@ func TestGraph(t *testing.T) {
 		t.Errorf("Template graph is wrong:\n%#v\n!=\n%#v\n", expected, actual)
 	}
 }
+
+func TestCopy(t *testing.T) {
+	original, _ := parse.String(`
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      Tags:
+        - Key: Name
+          Value: original
+`)
+
+	copied := original.Copy()
+
+	if !reflect.DeepEqual(original, copied) {
+		t.Fatalf("Copy differs from the original: %v", copied)
+	}
+
+	bucket := copied["Resources"].(map[string]interface{})["Bucket"].(map[string]interface{})
+	bucket["Properties"].(map[string]interface{})["Tags"].([]interface{})[0].(map[string]interface{})["Value"] = "changed"
+	bucket["Type"] = "AWS::SNS::Topic"
+
+	orig := original["Resources"].(map[string]interface{})["Bucket"].(map[string]interface{})
+	if orig["Type"] != "AWS::S3::Bucket" {
+		t.Error("Changing the copy changed the original's Type")
+	}
+
+	if orig["Properties"].(map[string]interface{})["Tags"].([]interface{})[0].(map[string]interface{})["Value"] != "original" {
+		t.Error("Changing the copy changed the original's Tags")
+	}
+}
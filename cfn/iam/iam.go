@@ -0,0 +1,470 @@
+// Package iam summarises the IAM permissions granted by a CloudFormation template
+package iam
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+)
+
+// Risk describes a potentially dangerous part of a Statement
+type Risk string
+
+const (
+	Wildcard Risk = "wildcard"
+	PassRole Risk = "iam:PassRole"
+	Admin    Risk = "admin"
+)
+
+// adminActions grant full access or allow a principal to grant itself full access
+var adminActions = []string{
+	"*",
+	"iam:*",
+	"iam:AttachGroupPolicy",
+	"iam:AttachRolePolicy",
+	"iam:AttachUserPolicy",
+	"iam:CreatePolicyVersion",
+	"iam:PutGroupPolicy",
+	"iam:PutRolePolicy",
+	"iam:PutUserPolicy",
+	"iam:UpdateAssumeRolePolicy",
+}
+
+// adminPolicies are AWS managed policies that are equivalent to admin access
+var adminPolicies = []string{
+	"AdministratorAccess",
+	"IAMFullAccess",
+}
+
+// Statement is a single statement from a policy document
+type Statement struct {
+	// Policy is the name of the policy that contains the statement
+	Policy string
+
+	Sid         string
+	Effect      string
+	Action      []string
+	NotAction   []string
+	Resource    []string
+	NotResource []string
+	Condition   bool
+}
+
+// Principal is an IAM role, user, or group along with the permissions granted to it
+type Principal struct {
+	// Name is the logical ID of the principal or its name if it is not defined in the template
+	Name string
+
+	// Type is the resource type of the principal; it is empty for principals defined outside the template
+	Type string
+
+	// AssumedBy lists the principals that are trusted to assume a role
+	AssumedBy []string
+
+	// ManagedPolicyArns lists managed policies that are not defined in the template
+	ManagedPolicyArns []string
+
+	Statements []Statement
+}
+
+var principalTypes = map[string]bool{
+	"AWS::IAM::Role":  true,
+	"AWS::IAM::User":  true,
+	"AWS::IAM::Group": true,
+}
+
+// Principals returns every IAM principal found in the template
+// along with the statements that apply to it, sorted by name
+func Principals(t cfn.Template) []Principal {
+	resources, _ := t["Resources"].(map[string]interface{})
+
+	principals := make(map[string]*Principal)
+	get := func(name, typeName string) *Principal {
+		if _, ok := principals[name]; !ok {
+			principals[name] = &Principal{Name: name, Type: typeName}
+		}
+		return principals[name]
+	}
+
+	// Policies that can be attached to principals, keyed by logical ID
+	policies := make(map[string][]Statement)
+	policyTypes := make(map[string]string)
+	attachments := make(map[string][]interface{})
+
+	for _, name := range sortedKeys(resources) {
+		resource, ok := resources[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		typeName, _ := resource["Type"].(string)
+		props, _ := resource["Properties"].(map[string]interface{})
+
+		switch {
+		case principalTypes[typeName]:
+			p := get(name, typeName)
+
+			if doc, ok := props["AssumeRolePolicyDocument"].(map[string]interface{}); ok {
+				p.AssumedBy = trustedPrincipals(doc)
+			}
+
+			for _, policy := range list(props["Policies"]) {
+				if policy, ok := policy.(map[string]interface{}); ok {
+					p.Statements = append(p.Statements, statements(Stringify(policy["PolicyName"]), policy["PolicyDocument"])...)
+				}
+			}
+		case typeName == "AWS::IAM::Policy", typeName == "AWS::IAM::ManagedPolicy":
+			policyName := name
+			if typeName == "AWS::IAM::Policy" && props["PolicyName"] != nil {
+				policyName = Stringify(props["PolicyName"])
+			}
+
+			policies[name] = statements(policyName, props["PolicyDocument"])
+			policyTypes[name] = typeName
+
+			for _, key := range []string{"Roles", "Users", "Groups"} {
+				attachments[name] = append(attachments[name], list(props[key])...)
+			}
+		}
+	}
+
+	// Managed policies attached from the principal's side
+	used := make(map[string]bool)
+	for _, name := range sortedKeys(resources) {
+		p, ok := principals[name]
+		if !ok {
+			continue
+		}
+
+		resource, _ := resources[name].(map[string]interface{})
+		props, _ := resource["Properties"].(map[string]interface{})
+
+		for _, arn := range list(props["ManagedPolicyArns"]) {
+			if ref := refName(arn); ref != "" {
+				if statements, ok := policies[ref]; ok {
+					p.Statements = append(p.Statements, statements...)
+					used[ref] = true
+					continue
+				}
+			}
+
+			p.ManagedPolicyArns = append(p.ManagedPolicyArns, Stringify(arn))
+		}
+	}
+
+	// Policies attached from the policy's side
+	for _, name := range sortedKeys(resources) {
+		statements, ok := policies[name]
+		if !ok {
+			continue
+		}
+
+		for _, target := range attachments[name] {
+			targetName := refName(target)
+			if targetName == "" {
+				targetName = Stringify(target)
+			}
+
+			p := get(targetName, "")
+			if !hasPolicy(p, statements) {
+				p.Statements = append(p.Statements, statements...)
+			}
+			used[name] = true
+		}
+
+		// Unattached policies are listed on their own
+		if !used[name] {
+			get(name, policyTypes[name]).Statements = statements
+		}
+	}
+
+	out := make([]Principal, 0, len(principals))
+	for _, name := range sortedPrincipalNames(principals) {
+		out = append(out, *principals[name])
+	}
+
+	return out
+}
+
+func hasPolicy(p *Principal, statements []Statement) bool {
+	if len(statements) == 0 {
+		return true
+	}
+
+	for _, s := range p.Statements {
+		if s.Policy == statements[0].Policy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Risks returns the risks associated with a Statement
+func (s Statement) Risks() []Risk {
+	risks := make([]Risk, 0)
+
+	if s.Effect != "Allow" {
+		return risks
+	}
+
+	if s.IsAdmin() {
+		risks = append(risks, Admin)
+	}
+
+	if s.AllowsPassRole() {
+		risks = append(risks, PassRole)
+	}
+
+	if len(s.NotAction) > 0 || len(s.NotResource) > 0 {
+		risks = append(risks, Wildcard)
+	} else {
+		for _, v := range append(append([]string{}, s.Action...), s.Resource...) {
+			if IsWildcard(v) {
+				risks = append(risks, Wildcard)
+				break
+			}
+		}
+	}
+
+	return risks
+}
+
+// IsAdmin returns true if the statement grants admin access
+// or enough IAM access for the principal to grant itself admin access
+func (s Statement) IsAdmin() bool {
+	if s.Effect != "Allow" || s.Condition {
+		return false
+	}
+
+	if !contains(s.Resource, "*") && len(s.NotResource) == 0 {
+		return false
+	}
+
+	if len(s.NotAction) > 0 {
+		for _, admin := range adminActions {
+			if !matchesAny(s.NotAction, admin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, action := range s.Action {
+		for _, admin := range adminActions {
+			if matches(action, admin) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AllowsPassRole returns true if the statement allows iam:PassRole
+func (s Statement) AllowsPassRole() bool {
+	if s.Effect != "Allow" {
+		return false
+	}
+
+	if len(s.NotAction) > 0 {
+		return !matchesAny(s.NotAction, string(PassRole))
+	}
+
+	return matchesAny(s.Action, string(PassRole))
+}
+
+// IsAdminPolicy returns true if arn refers to an AWS managed policy that grants admin access
+func IsAdminPolicy(arn string) bool {
+	for _, name := range adminPolicies {
+		if strings.HasSuffix(arn, ":policy/"+name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWildcard returns true if an action or resource contains a wildcard
+func IsWildcard(s string) bool {
+	return strings.Contains(s, "*")
+}
+
+// IsPassRole returns true if the action pattern matches iam:PassRole
+func IsPassRole(action string) bool {
+	return matches(action, string(PassRole))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches returns true if the IAM action pattern matches action
+func matches(pattern, action string) bool {
+	re := "(?i)^" + strings.Replace(strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1), `\?`, ".", -1) + "$"
+	ok, _ := regexp.MatchString(re, action)
+	return ok
+}
+
+func matchesAny(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if matches(pattern, action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func statements(policyName string, doc interface{}) []Statement {
+	out := make([]Statement, 0)
+
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		return out
+	}
+
+	for _, st := range statementsRaw(d) {
+		_, hasCondition := st["Condition"]
+
+		out = append(out, Statement{
+			Policy:      policyName,
+			Sid:         Stringify(st["Sid"]),
+			Effect:      Stringify(st["Effect"]),
+			Action:      toStrings(st["Action"]),
+			NotAction:   toStrings(st["NotAction"]),
+			Resource:    toStrings(st["Resource"]),
+			NotResource: toStrings(st["NotResource"]),
+			Condition:   hasCondition,
+		})
+	}
+
+	return out
+}
+
+func trustedPrincipals(doc map[string]interface{}) []string {
+	out := make([]string, 0)
+
+	for _, st := range statementsRaw(doc) {
+		switch p := st["Principal"].(type) {
+		case string:
+			out = append(out, p)
+		case map[string]interface{}:
+			for _, key := range sortedKeys(p) {
+				out = append(out, toStrings(p[key])...)
+			}
+		}
+	}
+
+	return out
+}
+
+func statementsRaw(doc map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0)
+
+	for _, r := range list(doc["Statement"]) {
+		if st, ok := r.(map[string]interface{}); ok {
+			out = append(out, st)
+		}
+	}
+
+	return out
+}
+
+// list returns v as a list, wrapping single values
+func list(v interface{}) []interface{} {
+	switch l := v.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return l
+	default:
+		return []interface{}{l}
+	}
+}
+
+// toStrings returns v as a list of strings
+func toStrings(v interface{}) []string {
+	out := make([]string, 0)
+
+	for _, item := range list(v) {
+		out = append(out, Stringify(item))
+	}
+
+	return out
+}
+
+// refName returns the logical ID referenced by v, if any
+func refName(v interface{}) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		if ref, ok := m["Ref"].(string); ok {
+			return ref
+		}
+	}
+
+	return ""
+}
+
+// Stringify returns a short, readable form of a value
+// that may contain intrinsic functions
+func Stringify(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case map[string]interface{}:
+		if len(value) == 1 {
+			for key, inner := range value {
+				name := strings.Replace(key, "Fn::", "", 1)
+
+				switch i := inner.(type) {
+				case []interface{}:
+					parts := make([]string, len(i))
+					for n, part := range i {
+						parts[n] = Stringify(part)
+					}
+
+					if name == "GetAtt" {
+						return fmt.Sprintf("!%s %s", name, strings.Join(parts, "."))
+					}
+
+					return fmt.Sprintf("!%s [%s]", name, strings.Join(parts, ", "))
+				default:
+					return fmt.Sprintf("!%s %s", name, Stringify(inner))
+				}
+			}
+		}
+	}
+
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedPrincipalNames(m map[string]*Principal) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,147 @@
+package iam_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/iam"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+var template, _ = parse.String(`
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Statement:
+          - Effect: Allow
+            Principal:
+              Service: lambda.amazonaws.com
+            Action: sts:AssumeRole
+      ManagedPolicyArns:
+        - arn:aws:iam::aws:policy/AdministratorAccess
+        - !Ref Managed
+      Policies:
+        - PolicyName: inline
+          PolicyDocument:
+            Statement:
+              - Effect: Allow
+                Action: s3:GetObject
+                Resource: !Sub arn:aws:s3:::${Bucket}/*
+  Managed:
+    Type: AWS::IAM::ManagedPolicy
+    Properties:
+      PolicyDocument:
+        Statement:
+          Effect: Allow
+          Action: iam:PassRole
+          Resource: !GetAtt Role.Arn
+  Policy:
+    Type: AWS::IAM::Policy
+    Properties:
+      PolicyName: admin
+      Roles:
+        - !Ref Role
+        - external-role
+      PolicyDocument:
+        Statement:
+          - Effect: Allow
+            Action: "*"
+            Resource: "*"
+  Bucket:
+    Type: AWS::S3::Bucket
+`)
+
+func TestPrincipals(t *testing.T) {
+	principals := iam.Principals(template)
+
+	if len(principals) != 2 {
+		t.Fatalf("Got %d principals, want 2: %#v", len(principals), principals)
+	}
+
+	role := principals[0]
+	if role.Name != "Role" || role.Type != "AWS::IAM::Role" {
+		t.Errorf("Unexpected principal: %s %s", role.Name, role.Type)
+	}
+
+	if !reflect.DeepEqual(role.AssumedBy, []string{"lambda.amazonaws.com"}) {
+		t.Errorf("Unexpected trust: %v", role.AssumedBy)
+	}
+
+	if !reflect.DeepEqual(role.ManagedPolicyArns, []string{"arn:aws:iam::aws:policy/AdministratorAccess"}) {
+		t.Errorf("Unexpected managed policies: %v", role.ManagedPolicyArns)
+	}
+
+	policies := make([]string, len(role.Statements))
+	for i, s := range role.Statements {
+		policies[i] = s.Policy
+	}
+	if !reflect.DeepEqual(policies, []string{"inline", "Managed", "admin"}) {
+		t.Errorf("Unexpected policies: %v", policies)
+	}
+
+	if role.Statements[0].Resource[0] != "!Sub arn:aws:s3:::${Bucket}/*" {
+		t.Errorf("Unexpected resource: %s", role.Statements[0].Resource[0])
+	}
+
+	external := principals[1]
+	if external.Name != "external-role" || external.Type != "" || len(external.Statements) != 1 {
+		t.Errorf("Unexpected principal: %#v", external)
+	}
+}
+
+func TestRisks(t *testing.T) {
+	cases := []iam.Statement{
+		{Effect: "Allow", Action: []string{"s3:GetObject"}, Resource: []string{"arn:aws:s3:::bucket/key"}},
+		{Effect: "Allow", Action: []string{"s3:GetObject"}, Resource: []string{"arn:aws:s3:::bucket/*"}},
+		{Effect: "Allow", Action: []string{"iam:PassRole"}, Resource: []string{"arn:aws:iam::123456789012:role/x"}},
+		{Effect: "Allow", Action: []string{"*"}, Resource: []string{"*"}},
+		{Effect: "Allow", NotAction: []string{"s3:*"}, Resource: []string{"*"}},
+		{Effect: "Deny", Action: []string{"*"}, Resource: []string{"*"}},
+	}
+
+	expecteds := [][]iam.Risk{
+		{},
+		{iam.Wildcard},
+		{iam.PassRole},
+		{iam.Admin, iam.PassRole, iam.Wildcard},
+		{iam.Admin, iam.PassRole, iam.Wildcard},
+		{},
+	}
+
+	for i, testCase := range cases {
+		actual := testCase.Risks()
+
+		if !reflect.DeepEqual(actual, expecteds[i]) {
+			t.Errorf("%#v: got %v, want %v", testCase, actual, expecteds[i])
+		}
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	cases := map[string]bool{
+		"*":                     true,
+		"*:*":                   true,
+		"iam:*":                 true,
+		"IAM:*":                 true,
+		"iam:Put*":              true,
+		"iam:Attach*":           true,
+		"iam:*Policy":           true,
+		"iam:AttachRolePolicy":  true,
+		"iam:Get*":              false,
+		"iam:PassRole":          false,
+		"s3:*":                  false,
+		"iam:CreateRole":        false,
+		"iam:List*":             false,
+		"iam:UpdateAssumeRole*": true,
+	}
+
+	for action, expected := range cases {
+		statement := iam.Statement{Effect: "Allow", Action: []string{action}, Resource: []string{"*"}}
+
+		if actual := statement.IsAdmin(); actual != expected {
+			t.Errorf("%s: got %t, want %t", action, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/spec"
+)
+
+var deletionPolicyRule = Rule{
+	Name:        "deletion-policy",
+	Description: "Stateful resources should have a DeletionPolicy",
+	Check:       checkDeletionPolicy,
+}
+
+var unusedParameterRule = Rule{
+	Name:        "unused-parameter",
+	Description: "Parameters should be used by the template",
+	Check:       checkUnusedParameters,
+}
+
+var joinRule = Rule{
+	Name:        "join-to-sub",
+	Description: "Fn::Join with an empty delimiter is clearer as Fn::Sub",
+	Check:       checkJoins,
+}
+
+func checkDeletionPolicy(t cfn.Template) []Problem {
+	problems := make([]Problem, 0)
+
+	resources, _ := t["Resources"].(map[string]interface{})
+	for _, name := range sortedKeys(resources) {
+		resource, ok := resources[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		typeName, _ := resource["Type"].(string)
+		if !spec.IsStateful(typeName) {
+			continue
+		}
+
+		if _, ok := resource["DeletionPolicy"]; ok {
+			continue
+		}
+
+		problems = append(problems, Problem{
+			Level:   Warning,
+			Path:    []interface{}{"Resources", name},
+			Message: fmt.Sprintf("%s has no DeletionPolicy; its data will be lost if it is removed from the template", typeName),
+			Fix: func(cfn.Template) {
+				resource["DeletionPolicy"] = "Retain"
+			},
+		})
+	}
+
+	return problems
+}
+
+func checkUnusedParameters(t cfn.Template) []Problem {
+	problems := make([]Problem, 0)
+
+	params, ok := t["Parameters"].(map[string]interface{})
+	if !ok {
+		return problems
+	}
+
+	used := make(map[string]bool)
+	walk(t, []interface{}{}, func(data interface{}, path []interface{}) {
+		if len(path) > 0 && path[0] == "Parameters" {
+			return
+		}
+
+		if m, ok := data.(map[string]interface{}); ok {
+			for _, name := range subRefs(m) {
+				used[strings.Split(name, ".")[0]] = true
+			}
+		}
+	})
+
+	for _, name := range sortedKeys(params) {
+		if used[name] {
+			continue
+		}
+
+		paramName := name
+		problems = append(problems, Problem{
+			Level:   Warning,
+			Path:    []interface{}{"Parameters", name},
+			Message: fmt.Sprintf("Parameter '%s' is not used", name),
+			Fix: func(t cfn.Template) {
+				params := t["Parameters"].(map[string]interface{})
+				delete(params, paramName)
+				if len(params) == 0 {
+					delete(t, "Parameters")
+				}
+
+				removeInterfaceParameter(t, paramName)
+			},
+		})
+	}
+
+	return problems
+}
+
+// removeInterfaceParameter removes a parameter from the groups and labels
+// in the template's AWS::CloudFormation::Interface metadata.
+// Groups that are left with no parameters are removed.
+func removeInterfaceParameter(t cfn.Template, name string) {
+	metadata, _ := t["Metadata"].(map[string]interface{})
+	iface, ok := metadata["AWS::CloudFormation::Interface"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if labels, ok := iface["ParameterLabels"].(map[string]interface{}); ok {
+		delete(labels, name)
+	}
+
+	groups, ok := iface["ParameterGroups"].([]interface{})
+	if !ok {
+		return
+	}
+
+	keptGroups := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		group, ok := g.(map[string]interface{})
+		if !ok {
+			keptGroups = append(keptGroups, g)
+			continue
+		}
+
+		params, ok := group["Parameters"].([]interface{})
+		if !ok {
+			keptGroups = append(keptGroups, g)
+			continue
+		}
+
+		kept := make([]interface{}, 0, len(params))
+		for _, param := range params {
+			if param != name {
+				kept = append(kept, param)
+			}
+		}
+
+		if len(kept) > 0 {
+			group["Parameters"] = kept
+			keptGroups = append(keptGroups, group)
+		}
+	}
+
+	iface["ParameterGroups"] = keptGroups
+}
+
+func checkJoins(t cfn.Template) []Problem {
+	problems := make([]Problem, 0)
+
+	walk(t, []interface{}{}, func(data interface{}, path []interface{}) {
+		m, ok := data.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return
+		}
+
+		join, ok := m["Fn::Join"].([]interface{})
+		if !ok || len(join) != 2 || join[0] != "" {
+			return
+		}
+
+		parts, ok := join[1].([]interface{})
+		if !ok {
+			return
+		}
+
+		sub, ok := joinToSub(parts)
+		if !ok {
+			return
+		}
+
+		problems = append(problems, Problem{
+			Level:   Warning,
+			Path:    path,
+			Message: "Fn::Join with an empty delimiter can be replaced with Fn::Sub",
+			Fix: func(cfn.Template) {
+				delete(m, "Fn::Join")
+				m["Fn::Sub"] = sub
+			},
+		})
+	})
+
+	return problems
+}
+
+// joinToSub returns an Fn::Sub string equivalent to joining parts
+// or false if any of the parts can't be represented in a Sub
+func joinToSub(parts []interface{}) (string, bool) {
+	out := strings.Builder{}
+
+	for _, part := range parts {
+		switch v := part.(type) {
+		case string:
+			out.WriteString(strings.Replace(v, "${", "${!", -1))
+		case map[string]interface{}:
+			if len(v) != 1 {
+				return "", false
+			}
+
+			if ref, ok := v["Ref"].(string); ok {
+				out.WriteString("${" + ref + "}")
+			} else if getAtt, ok := v["Fn::GetAtt"]; ok {
+				switch g := getAtt.(type) {
+				case string:
+					out.WriteString("${" + g + "}")
+				case []interface{}:
+					if len(g) != 2 {
+						return "", false
+					}
+
+					resource, ok1 := g[0].(string)
+					attribute, ok2 := g[1].(string)
+					if !ok1 || !ok2 {
+						return "", false
+					}
+					out.WriteString("${" + resource + "." + attribute + "}")
+				default:
+					return "", false
+				}
+			} else {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+
+	return out.String(), true
+}
@@ -0,0 +1,132 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/lint"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+func TestFix(t *testing.T) {
+	template, err := parse.String(`
+Metadata:
+  AWS::CloudFormation::Interface:
+    ParameterGroups:
+      - Label:
+          default: Names
+        Parameters:
+          - Used
+          - Unused
+      - Label:
+          default: Old
+        Parameters:
+          - Unused
+    ParameterLabels:
+      Used:
+        default: Table name
+      Unused:
+        default: Nothing
+Parameters:
+  Used:
+    Type: String
+  Conditional:
+    Type: String
+  Unused:
+    Type: String
+Conditions:
+  IsProd: !Equals [!Ref Conditional, prod]
+Resources:
+  Table:
+    Type: AWS::DynamoDB::Table
+    Properties:
+      TableName: !Join
+        - ""
+        - - !Ref Used
+          - "-"
+          - !GetAtt Bucket.Arn
+          - "-${literal}"
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Join
+        - "-"
+        - - a
+          - b
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := lint.Check(template)
+
+	expected := []string{
+		"Parameters/Unused: Parameter 'Unused' is not used (unused-parameter)",
+		"Resources/Bucket: AWS::S3::Bucket has no DeletionPolicy; its data will be lost if it is removed from the template (deletion-policy)",
+		"Resources/Table: AWS::DynamoDB::Table has no DeletionPolicy; its data will be lost if it is removed from the template (deletion-policy)",
+		"Resources/Table/Properties/TableName: Fn::Join with an empty delimiter can be replaced with Fn::Sub (join-to-sub)",
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("Got %d problems, want %d: %v", len(problems), len(expected), problems)
+	}
+
+	for i, problem := range problems {
+		if problem.String() != expected[i] {
+			t.Errorf("Got %q, want %q", problem.String(), expected[i])
+		}
+	}
+
+	if n := lint.Fix(template, problems); n != 4 {
+		t.Errorf("Applied %d fixes, want 4", n)
+	}
+
+	actual := format.Template(template, format.Options{})
+
+	want := `Parameters:
+  Conditional:
+    Type: String
+
+  Used:
+    Type: String
+
+Metadata:
+  "AWS::CloudFormation::Interface":
+    ParameterGroups:
+      - Label:
+          default: Names
+        Parameters:
+          - Used
+    ParameterLabels:
+      Used:
+        default: Table name
+
+Conditions:
+  IsProd: !Equals
+    - Ref: Conditional
+    - prod
+
+Resources:
+  Bucket:
+    Type: "AWS::S3::Bucket"
+    DeletionPolicy: Retain
+    Properties:
+      BucketName: !Join
+        - "-"
+        - - a
+          - b
+
+  Table:
+    Type: "AWS::DynamoDB::Table"
+    DeletionPolicy: Retain
+    Properties:
+      TableName: !Sub ${Used}-${Bucket.Arn}-${!literal}`
+
+	if actual != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", actual, want)
+	}
+
+	if len(lint.Check(template)) != 0 {
+		t.Errorf("Problems remain after fixing: %v", lint.Check(template))
+	}
+}
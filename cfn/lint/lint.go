@@ -0,0 +1,170 @@
+// Package lint provides rules that check a cfn.Template
+// for problems that CloudFormation itself would not report
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+)
+
+// Level represents the severity of a Problem
+type Level int
+
+const (
+	Warning Level = iota
+	Error
+)
+
+func (l Level) String() string {
+	if l == Error {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// Problem represents a single issue found in a template
+type Problem struct {
+	// Rule is the name of the Rule that found the problem
+	Rule string
+
+	// Level is the severity of the problem
+	Level Level
+
+	// Path identifies the location of the problem within the template
+	// in the same format as value.Value.Get
+	Path []interface{}
+
+	// Message describes the problem
+	Message string
+
+	// Fix, if set, modifies the template to resolve the problem
+	Fix func(cfn.Template)
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s (%s)", FormatPath(p.Path), p.Message, p.Rule)
+}
+
+// Rule is a named check that can be run against a template
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(cfn.Template) []Problem
+}
+
+// Rules contains the built-in rules that are run by Check
+var Rules = []Rule{
+	secretsRule,
+	deletionPolicyRule,
+	unusedParameterRule,
+	joinRule,
+}
+
+// Check runs each of the supplied rules against the template
+// and returns any problems found, ordered by their location in the template.
+// If no rules are supplied, the built-in Rules are used.
+func Check(t cfn.Template, rules ...Rule) []Problem {
+	if len(rules) == 0 {
+		rules = Rules
+	}
+
+	problems := make([]Problem, 0)
+
+	for _, rule := range rules {
+		for _, problem := range rule.Check(t) {
+			problem.Rule = rule.Name
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return FormatPath(problems[i].Path) < FormatPath(problems[j].Path)
+	})
+
+	return problems
+}
+
+// HasErrors returns true if any of the problems are at the Error level
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if problem.Level == Error {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fix applies the fixes for any problems that have one
+// and returns the number of fixes applied
+func Fix(t cfn.Template, problems []Problem) int {
+	count := 0
+
+	for _, problem := range problems {
+		if problem.Fix != nil {
+			problem.Fix(t)
+			count++
+		}
+	}
+
+	return count
+}
+
+// FormatPath returns a human-readable representation of a path
+func FormatPath(path []interface{}) string {
+	parts := make([]string, len(path))
+
+	for i, part := range path {
+		if index, ok := part.(int); ok {
+			parts[i] = fmt.Sprintf("[%d]", index)
+		} else {
+			parts[i] = fmt.Sprint(part)
+		}
+	}
+
+	return strings.Replace(strings.Join(parts, "/"), "/[", "[", -1)
+}
+
+// walk calls fn for every value within data, depth first,
+// along with the path that leads to it
+func walk(data interface{}, path []interface{}, fn func(interface{}, []interface{})) {
+	if t, ok := data.(cfn.Template); ok {
+		data = t.Map()
+	}
+
+	fn(data, path)
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			walk(v[key], appendPath(path, key), fn)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walk(child, appendPath(path, i), fn)
+		}
+	}
+}
+
+// appendPath returns a copy of path with part appended
+// so that paths can be safely retained by callers of walk
+func appendPath(path []interface{}, part interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+
+	return append(out, part)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,286 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/value"
+
+	yamlwrapper "github.com/sanathkr/yaml"
+)
+
+// Policy is a user-defined rule that checks a property of every resource of a given type.
+//
+// Policies are written in YAML, for example:
+//
+//	Rules:
+//	  - Name: bucket-encryption
+//	    Resource: AWS::S3::Bucket
+//	    Path: Properties.BucketEncryption
+//	    Exists: true
+//	  - Name: instance-types
+//	    Level: warning
+//	    Resource: AWS::EC2::Instance
+//	    Path: Properties.InstanceType
+//	    In: ["t3.*"]
+type Policy struct {
+	// Name identifies the policy in lint output
+	Name string `json:"Name"`
+
+	// Message is displayed when the policy is broken.
+	// If it is empty, a message is generated from the policy's operators.
+	Message string `json:"Message"`
+
+	// Level is either "error" (the default) or "warning"
+	Level string `json:"Level"`
+
+	// Resource selects resource types to check. It may contain wildcards, e.g. AWS::S3::*
+	Resource string `json:"Resource"`
+
+	// Path is the location of the value to check within each resource
+	// with each part separated by ".", e.g. Properties.Tags.0.Key
+	Path string `json:"Path"`
+
+	// Exists requires the value to be set (true) or not set (false)
+	Exists *bool `json:"Exists"`
+
+	// Equals requires the value to be equal to the one given
+	Equals interface{} `json:"Equals"`
+
+	// Matches requires the value to match a regular expression
+	Matches string `json:"Matches"`
+
+	// In requires the value to be one of those given.
+	// String values may contain wildcards, e.g. t3.*
+	In []interface{} `json:"In"`
+}
+
+type policyFile struct {
+	Rules []Policy `json:"Rules"`
+}
+
+var intRe = regexp.MustCompile(`^[0-9]+$`)
+
+// LoadPolicies reads every YAML file in dir and returns the policies defined there as Rules.
+// If dir does not exist, no rules are returned.
+func LoadPolicies(dir string) ([]Rule, error) {
+	rules := make([]Rule, 0)
+
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		fns, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fn := range fns {
+			data, err := ioutil.ReadFile(fn)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read '%s': %s", fn, err)
+			}
+
+			policies, err := ParsePolicies(data)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid rules in '%s': %s", fn, err)
+			}
+
+			rules = append(rules, policies...)
+		}
+	}
+
+	return rules, nil
+}
+
+// ParsePolicies returns the policies defined in a YAML document as Rules
+func ParsePolicies(data []byte) ([]Rule, error) {
+	var file policyFile
+
+	err := yamlwrapper.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, len(file.Rules))
+	for i, policy := range file.Rules {
+		rule, err := policy.Rule()
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = rule
+	}
+
+	return rules, nil
+}
+
+// Rule validates the policy and returns a Rule that applies it
+func (p Policy) Rule() (Rule, error) {
+	if p.Name == "" {
+		return Rule{}, errors.New("Rule has no Name")
+	}
+
+	if p.Resource == "" {
+		return Rule{}, fmt.Errorf("Rule '%s' has no Resource", p.Name)
+	}
+
+	if _, err := path.Match(p.Resource, ""); err != nil {
+		return Rule{}, fmt.Errorf("Rule '%s' has an invalid Resource: %s", p.Name, err)
+	}
+
+	if p.Path == "" {
+		return Rule{}, fmt.Errorf("Rule '%s' has no Path", p.Name)
+	}
+
+	if p.Exists == nil && p.Equals == nil && p.Matches == "" && p.In == nil {
+		return Rule{}, fmt.Errorf("Rule '%s' needs at least one of Exists, Equals, Matches, or In", p.Name)
+	}
+
+	level := Error
+	switch strings.ToLower(p.Level) {
+	case "", "error":
+	case "warning":
+		level = Warning
+	default:
+		return Rule{}, fmt.Errorf("Rule '%s' has an invalid Level '%s'", p.Name, p.Level)
+	}
+
+	var matches *regexp.Regexp
+	if p.Matches != "" {
+		var err error
+		matches, err = regexp.Compile(p.Matches)
+		if err != nil {
+			return Rule{}, fmt.Errorf("Rule '%s' has an invalid Matches: %s", p.Name, err)
+		}
+	}
+
+	propPath := make([]interface{}, 0)
+	for _, part := range strings.Split(p.Path, ".") {
+		if intRe.MatchString(part) {
+			i, _ := strconv.Atoi(part)
+			propPath = append(propPath, i)
+		} else {
+			propPath = append(propPath, part)
+		}
+	}
+
+	return Rule{
+		Name:        p.Name,
+		Description: p.Message,
+		Check: func(t cfn.Template) []Problem {
+			return p.check(t, level, propPath, matches)
+		},
+	}, nil
+}
+
+func (p Policy) check(t cfn.Template, level Level, propPath []interface{}, matches *regexp.Regexp) []Problem {
+	problems := make([]Problem, 0)
+
+	resources, _ := t["Resources"].(map[string]interface{})
+	for _, name := range sortedKeys(resources) {
+		resource, ok := resources[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		typeName, _ := resource["Type"].(string)
+		if ok, _ := path.Match(p.Resource, typeName); !ok {
+			continue
+		}
+
+		v, found := lookup(value.New(resource, nil), propPath)
+
+		message := ""
+		switch {
+		case p.Exists != nil && *p.Exists && !found:
+			message = fmt.Sprintf("%s must be set", p.Path)
+		case p.Exists != nil && !*p.Exists && found:
+			message = fmt.Sprintf("%s must not be set", p.Path)
+		case !found || isIntrinsic(v):
+			// Nothing we can compare against
+		case p.Equals != nil && !equal(v, p.Equals):
+			message = fmt.Sprintf("%s must be %v", p.Path, p.Equals)
+		case matches != nil && !matches.MatchString(fmt.Sprint(v)):
+			message = fmt.Sprintf("%s must match '%s'", p.Path, p.Matches)
+		case p.In != nil && !in(v, p.In):
+			message = fmt.Sprintf("%s must be one of %v", p.Path, p.In)
+		}
+
+		if message == "" {
+			continue
+		}
+
+		if p.Message != "" {
+			message = p.Message
+		}
+
+		problems = append(problems, Problem{
+			Level:   level,
+			Path:    append([]interface{}{"Resources", name}, propPath...),
+			Message: message,
+		})
+	}
+
+	return problems
+}
+
+// lookup returns the value at path and whether it was found
+func lookup(v value.Value, path []interface{}) (out interface{}, found bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, found = nil, false
+		}
+	}()
+
+	out = v.Get(path...)
+
+	return out, out != nil
+}
+
+func isIntrinsic(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return false
+	}
+
+	for key := range m {
+		return key == "Ref" || strings.HasPrefix(key, "Fn::")
+	}
+
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	switch a.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func in(v interface{}, options []interface{}) bool {
+	for _, option := range options {
+		if pattern, ok := option.(string); ok {
+			if ok, _ := path.Match(pattern, fmt.Sprint(v)); ok {
+				return true
+			}
+		}
+
+		if equal(v, option) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package lint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/lint"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+func TestPolicies(t *testing.T) {
+	rules, err := lint.ParsePolicies([]byte(`
+Rules:
+  - Name: bucket-encryption
+    Resource: AWS::S3::Bucket
+    Path: Properties.BucketEncryption
+    Exists: true
+  - Name: instance-types
+    Level: warning
+    Resource: AWS::EC2::*
+    Path: Properties.InstanceType
+    In: ["t3.*"]
+  - Name: first-tag
+    Message: The first tag must be Owner
+    Resource: AWS::EC2::Instance
+    Path: Properties.Tags.0.Key
+    Equals: Owner
+  - Name: ami
+    Resource: AWS::EC2::Instance
+    Path: Properties.ImageId
+    Matches: ^ami-[0-9a-f]+$
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := parse.String(`
+Resources:
+  Encrypted:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketEncryption: {}
+  Unencrypted:
+    Type: AWS::S3::Bucket
+  Small:
+    Type: AWS::EC2::Instance
+    Properties:
+      InstanceType: t3.micro
+      ImageId: ami-0123abcd
+      Tags:
+        - Key: Owner
+          Value: me
+  Large:
+    Type: AWS::EC2::Instance
+    Properties:
+      InstanceType: m5.24xlarge
+      ImageId: !Ref Image
+      Tags:
+        - Key: Name
+          Value: large
+  Bad:
+    Type: AWS::EC2::Instance
+    Properties:
+      InstanceType: !Ref Type
+      ImageId: not-an-ami
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := lint.Check(template, rules...)
+
+	expected := []string{
+		"Resources/Bad/Properties/ImageId: Properties.ImageId must match '^ami-[0-9a-f]+$' (ami)",
+		"Resources/Large/Properties/InstanceType: Properties.InstanceType must be one of [t3.*] (instance-types)",
+		"Resources/Large/Properties/Tags[0]/Key: The first tag must be Owner (first-tag)",
+		"Resources/Unencrypted/Properties/BucketEncryption: Properties.BucketEncryption must be set (bucket-encryption)",
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("Got %d problems, want %d: %v", len(problems), len(expected), problems)
+	}
+
+	for i, problem := range problems {
+		if problem.String() != expected[i] {
+			t.Errorf("Got %q, want %q", problem.String(), expected[i])
+		}
+	}
+
+	if problems[1].Level != lint.Warning {
+		t.Errorf("Expected a warning: %s", problems[1])
+	}
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	valid := `
+Rules:
+  - Name: x
+    Resource: AWS::S3::Bucket
+    Path: Properties
+    Exists: true
+`
+
+	if _, err := lint.ParsePolicies([]byte(valid)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []string{
+		strings.Replace(valid, "Name: x", "Description: x", 1),
+		strings.Replace(valid, "Resource: AWS::S3::Bucket", "Type: AWS::S3::Bucket", 1),
+		strings.Replace(valid, "Path: Properties", "Property: Properties", 1),
+		strings.Replace(valid, "Exists: true", "Exist: true", 1),
+		strings.Replace(valid, "Exists: true", "Exists: true\n    Level: fatal", 1),
+		strings.Replace(valid, "Exists: true", "Matches: \"[\"", 1),
+		"Rules: {}",
+	}
+
+	for _, testCase := range cases {
+		if _, err := lint.ParsePolicies([]byte(testCase)); err == nil {
+			t.Errorf("Expected an error from %s", testCase)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+)
+
+var secretsRule = Rule{
+	Name:        "secrets",
+	Description: "Find credentials and other secrets stored in plain text",
+	Check:       checkSecrets,
+}
+
+// passwordKeyRe matches the names of properties that are likely to hold a secret
+var passwordKeyRe = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|credential|apikey|api_key|privatekey|private_key)`)
+
+// notPasswordKeyRe matches property names that mention secrets
+// but only ever identify one, e.g. SecretArn
+var notPasswordKeyRe = regexp.MustCompile(`(?i)(arn|arns|id|ids|name|type|length|url|uri|template|policy|units|validity|required)$`)
+
+// roleArnProperties lists the properties whose names look like secrets
+// but which hold the ARN of an IAM role
+var roleArnProperties = map[string][]string{
+	"AWS::ApiGateway::Authorizer": {"AuthorizerCredentials"},
+	"AWS::ApiGateway::Method":     {"Integration/Credentials"},
+}
+
+var secretPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{24,}\b`)},
+}
+
+var base64Re = regexp.MustCompile(`^[A-Za-z0-9+/_-]+=*$`)
+var hexRe = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+var digitRe = regexp.MustCompile(`[0-9]`)
+var letterRe = regexp.MustCompile(`[A-Za-z]`)
+
+const (
+	minEntropyLength = 20
+	base64Entropy    = 4.5
+	hexEntropy       = 3.0
+	minHexLength     = 32
+)
+
+// isDynamicReference returns true if s contains a reference to
+// a value stored in Secrets Manager or as an SSM SecureString
+func isDynamicReference(s string) bool {
+	return strings.Contains(s, "{{resolve:secretsmanager:") || strings.Contains(s, "{{resolve:ssm-secure:")
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	total := float64(len([]rune(s)))
+
+	e := 0.0
+	for _, count := range counts {
+		p := float64(count) / total
+		e -= p * math.Log2(p)
+	}
+
+	return e
+}
+
+// looksRandom returns true if s appears to be a randomly generated key or token
+func looksRandom(s string) bool {
+	if len(s) < minEntropyLength || isDynamicReference(s) {
+		return false
+	}
+
+	if hexRe.MatchString(s) {
+		return len(s) >= minHexLength && entropy(s) >= hexEntropy
+	}
+
+	if !base64Re.MatchString(s) || !digitRe.MatchString(s) || !letterRe.MatchString(s) {
+		return false
+	}
+
+	return entropy(s) >= base64Entropy
+}
+
+func isPasswordKey(key string) bool {
+	return passwordKeyRe.MatchString(key) && !notPasswordKeyRe.MatchString(key)
+}
+
+// isRoleArnProperty returns true if path is one of the roleArnProperties of its resource
+func isRoleArnProperty(t cfn.Template, path []interface{}) bool {
+	if len(path) < 4 || path[0] != "Resources" || path[2] != "Properties" {
+		return false
+	}
+
+	resources, _ := t["Resources"].(map[string]interface{})
+	resource, _ := resources[fmt.Sprint(path[1])].(map[string]interface{})
+	typeName, _ := resource["Type"].(string)
+
+	for _, property := range roleArnProperties[typeName] {
+		if FormatPath(path[3:]) == property {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNoEcho(param interface{}) bool {
+	p, ok := param.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	return fmt.Sprint(p["NoEcho"]) == "true"
+}
+
+func checkSecrets(t cfn.Template) []Problem {
+	problems := make([]Problem, 0)
+	flagged := make(map[string]bool)
+
+	params, _ := t["Parameters"].(map[string]interface{})
+
+	// Password-like properties must come from NoEcho parameters or dynamic references
+	walk(t, []interface{}{}, func(data interface{}, path []interface{}) {
+		if len(path) < 2 || path[0] == "Parameters" || path[0] == "Metadata" {
+			return
+		}
+
+		key, ok := path[len(path)-1].(string)
+		if !ok || !isPasswordKey(key) || isRoleArnProperty(t, path) {
+			return
+		}
+
+		switch v := data.(type) {
+		case string:
+			if v == "" || isDynamicReference(v) {
+				return
+			}
+
+			flagged[FormatPath(path)] = true
+			problems = append(problems, Problem{
+				Level:   Error,
+				Path:    path,
+				Message: fmt.Sprintf("'%s' is set to a plain text value; use a NoEcho parameter or a dynamic reference", key),
+			})
+		case map[string]interface{}:
+			for _, name := range subRefs(v) {
+				if param, ok := params[name]; ok && !isNoEcho(param) {
+					problems = append(problems, Problem{
+						Level:   Error,
+						Path:    path,
+						Message: fmt.Sprintf("'%s' refers to parameter '%s' which does not have NoEcho set", key, name),
+					})
+				}
+			}
+		}
+	})
+
+	// NoEcho parameters should not have their value stored in the template
+	for name, param := range params {
+		if !isNoEcho(param) {
+			continue
+		}
+
+		if d, ok := param.(map[string]interface{})["Default"].(string); ok && d != "" && !isDynamicReference(d) {
+			path := []interface{}{"Parameters", name, "Default"}
+			flagged[FormatPath(path)] = true
+			problems = append(problems, Problem{
+				Level:   Error,
+				Path:    path,
+				Message: fmt.Sprintf("NoEcho parameter '%s' has a plain text default value", name),
+			})
+		}
+	}
+
+	// Known credential formats and high-entropy strings can appear anywhere
+	walk(t, []interface{}{}, func(data interface{}, path []interface{}) {
+		s, ok := data.(string)
+		if !ok || flagged[FormatPath(path)] {
+			return
+		}
+
+		for _, pattern := range secretPatterns {
+			if pattern.re.MatchString(s) {
+				flagged[FormatPath(path)] = true
+				problems = append(problems, Problem{
+					Level:   Error,
+					Path:    path,
+					Message: fmt.Sprintf("Value looks like a %s", pattern.name),
+				})
+				return
+			}
+		}
+
+		if looksRandom(s) {
+			problems = append(problems, Problem{
+				Level:   Warning,
+				Path:    path,
+				Message: "Value has high entropy and may be a secret",
+			})
+		}
+	})
+
+	return problems
+}
+
+var subRefRe = regexp.MustCompile(`\$\{([^!][^}]*)\}`)
+
+// subRefs returns the names referred to by a Ref or Fn::Sub intrinsic function
+func subRefs(data map[string]interface{}) []string {
+	refs := make([]string, 0)
+
+	if ref, ok := data["Ref"].(string); ok {
+		refs = append(refs, ref)
+	}
+
+	switch v := data["Fn::Sub"].(type) {
+	case string:
+		for _, groups := range subRefRe.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, groups[1])
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				for _, groups := range subRefRe.FindAllStringSubmatch(s, -1) {
+					refs = append(refs, groups[1])
+				}
+			}
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,400 @@
+// Package manifest reads rain.yaml files that describe a set of stacks
+// to be deployed together, and works out the order they must be deployed in
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/graph"
+	"github.com/aws-cloudformation/rain/cfn/params"
+
+	yamlwrapper "github.com/sanathkr/yaml"
+)
+
+// Stack describes a single stack in a manifest
+type Stack struct {
+	// Name is the name of the stack, taken from its key in the manifest
+	Name string `json:"-"`
+
+	// Template is the path to the stack's template,
+	// relative to the directory that contains the manifest
+	Template string
+
+	// Region and Profile override the AWS region and profile used for the stack
+	Region  string
+	Profile string
+
+	// Parameters holds the stack's parameter values.
+	// Values may refer to other stacks' outputs using ${stack.Output}.
+	Parameters map[string]string
+
+	// Tags are applied to the stack
+	Tags map[string]string
+
+	// DependsOn lists stacks that must be deployed before this one
+	DependsOn []string
+}
+
+// Manifest is a set of stacks to deploy together
+type Manifest struct {
+	// Dir is the directory that template paths are relative to
+	Dir string
+
+	Stacks map[string]*Stack
+}
+
+// Reference is a parameter value's reference to another stack's output
+type Reference struct {
+	Stack  string
+	Output string
+}
+
+var referencePattern = regexp.MustCompile(`\$\{([A-Za-z][A-Za-z0-9-]*)\.([A-Za-z0-9]+)\}`)
+
+// Load reads a manifest from the named file
+func Load(fn string) (Manifest, error) {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("Unable to read manifest '%s': %s", fn, err)
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return m, fmt.Errorf("Unable to parse manifest '%s': %s", fn, err)
+	}
+
+	m.Dir = filepath.Dir(fn)
+
+	return m, nil
+}
+
+// Parse reads a manifest from YAML or JSON
+func Parse(data []byte) (Manifest, error) {
+	m := Manifest{
+		Dir:    ".",
+		Stacks: make(map[string]*Stack),
+	}
+
+	j, err := yamlwrapper.YAMLToJSON(data)
+	if err != nil {
+		return m, fmt.Errorf("Invalid YAML: %s", err)
+	}
+
+	var raw struct {
+		Stacks map[string]struct {
+			Template   string
+			Region     string
+			Profile    string
+			Parameters map[string]interface{}
+			Tags       map[string]interface{}
+			DependsOn  []string
+		}
+	}
+
+	err = json.Unmarshal(j, &raw)
+	if err != nil {
+		return m, fmt.Errorf("Invalid manifest: %s", err)
+	}
+
+	if len(raw.Stacks) == 0 {
+		return m, errors.New("The manifest does not contain any Stacks")
+	}
+
+	for name, s := range raw.Stacks {
+		if s.Template == "" {
+			return m, fmt.Errorf("Stack '%s' has no Template", name)
+		}
+
+		stack := &Stack{
+			Name:       name,
+			Template:   s.Template,
+			Region:     s.Region,
+			Profile:    s.Profile,
+			Parameters: make(map[string]string),
+			Tags:       make(map[string]string),
+			DependsOn:  s.DependsOn,
+		}
+
+		for key, value := range s.Parameters {
+			stack.Parameters[key] = params.Stringify(value)
+		}
+
+		for key, value := range s.Tags {
+			stack.Tags[key] = params.Stringify(value)
+		}
+
+		m.Stacks[name] = stack
+	}
+
+	for _, stack := range m.Stacks {
+		for _, dep := range stack.DependsOn {
+			if _, ok := m.Stacks[dep]; !ok {
+				return m, fmt.Errorf("Stack '%s' depends on unknown stack '%s'", stack.Name, dep)
+			}
+		}
+
+		for _, ref := range stack.References() {
+			if _, ok := m.Stacks[ref.Stack]; !ok {
+				return m, fmt.Errorf("Stack '%s' refers to an output of unknown stack '%s'", stack.Name, ref.Stack)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Names returns the names of the manifest's stacks in alphabetical order
+func (m Manifest) Names() []string {
+	names := make([]string, 0, len(m.Stacks))
+	for name := range m.Stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// TemplatePath returns the path of the stack's template
+func (m Manifest) TemplatePath(s *Stack) string {
+	if filepath.IsAbs(s.Template) {
+		return s.Template
+	}
+
+	return filepath.Join(m.Dir, s.Template)
+}
+
+// References returns the outputs of other stacks that the stack's parameters refer to
+func (s *Stack) References() []Reference {
+	refs := make([]Reference, 0)
+
+	for _, key := range sortedKeys(s.Parameters) {
+		for _, match := range referencePattern.FindAllStringSubmatch(s.Parameters[key], -1) {
+			refs = append(refs, Reference{match[1], match[2]})
+		}
+	}
+
+	return refs
+}
+
+// ResolveParameters returns the stack's parameter values
+// with references replaced by the values in outputs,
+// which maps stack names to output names to values
+func (s *Stack) ResolveParameters(outputs map[string]map[string]string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for key, value := range s.Parameters {
+		var err error
+
+		values[key] = referencePattern.ReplaceAllStringFunc(value, func(ref string) string {
+			match := referencePattern.FindStringSubmatch(ref)
+
+			out, ok := outputs[match[1]][match[2]]
+			if !ok {
+				err = fmt.Errorf("Stack '%s' has no output named '%s'", match[1], match[2])
+			}
+
+			return out
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to resolve parameter '%s' of stack '%s': %s", key, s.Name, err)
+		}
+	}
+
+	return values, nil
+}
+
+// Order returns the manifest's stacks grouped into waves.
+// Each wave only depends on stacks in earlier waves
+// so the stacks in a wave can be deployed in parallel.
+//
+// As well as DependsOn and output references, a stack depends on any stack
+// that exports a value it imports; templates maps stack names to their templates.
+func (m Manifest) Order(templates map[string]cfn.Template) ([][]string, error) {
+	g := graph.New()
+
+	exporters := make(map[string]string)
+	for name, t := range templates {
+		for _, export := range Exports(t, name) {
+			exporters[export] = name
+		}
+	}
+
+	for _, name := range m.Names() {
+		stack := m.Stacks[name]
+
+		deps := make([]interface{}, 0)
+		for _, dep := range stack.DependsOn {
+			deps = append(deps, dep)
+		}
+
+		for _, ref := range stack.References() {
+			deps = append(deps, ref.Stack)
+		}
+
+		for _, imp := range Imports(templates[name]) {
+			if exporter, ok := exporters[imp]; ok && exporter != name {
+				deps = append(deps, exporter)
+			}
+		}
+
+		g.Add(name, deps...)
+	}
+
+	levels := make(map[string]int)
+	visiting := make(map[string]bool)
+
+	var level func(string) (int, error)
+	level = func(name string) (int, error) {
+		if l, ok := levels[name]; ok {
+			return l, nil
+		}
+
+		if visiting[name] {
+			return 0, fmt.Errorf("Stack '%s' depends on itself", name)
+		}
+		visiting[name] = true
+
+		l := 0
+		for _, dep := range g.Get(name) {
+			depLevel, err := level(dep.(string))
+			if err != nil {
+				return 0, err
+			}
+
+			if depLevel+1 > l {
+				l = depLevel + 1
+			}
+		}
+
+		levels[name] = l
+		return l, nil
+	}
+
+	waves := make([][]string, 0)
+	for _, name := range m.Names() {
+		l, err := level(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for len(waves) <= l {
+			waves = append(waves, make([]string, 0))
+		}
+
+		waves[l] = append(waves[l], name)
+	}
+
+	return waves, nil
+}
+
+// Exports returns the names of the values that the template exports.
+// Names that can't be worked out without deploying the stack are ignored.
+func Exports(t cfn.Template, stackName string) []string {
+	exports := make([]string, 0)
+
+	outputs, _ := t["Outputs"].(map[string]interface{})
+	for _, key := range sortedKeys(outputs) {
+		output, ok := outputs[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		export, ok := output["Export"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if name, ok := staticString(export["Name"], stackName); ok {
+			exports = append(exports, name)
+		}
+	}
+
+	return exports
+}
+
+// Imports returns the names of the values that the template imports with Fn::ImportValue.
+// Names that can't be worked out without deploying the stack are ignored.
+func Imports(t cfn.Template) []string {
+	imports := make([]string, 0)
+
+	var walk func(interface{})
+	walk = func(data interface{}) {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedKeys(v) {
+				if key == "Fn::ImportValue" {
+					if name, ok := staticString(v[key], ""); ok {
+						imports = append(imports, name)
+						continue
+					}
+				}
+
+				walk(v[key])
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	if t != nil {
+		walk(t.Map())
+	}
+
+	return imports
+}
+
+// staticString returns the value of a string or a Fn::Sub with no variables
+// other than AWS::StackName, which is replaced with stackName if it is set
+func staticString(data interface{}, stackName string) (string, bool) {
+	switch v := data.(type) {
+	case string:
+		return v, true
+	case map[string]interface{}:
+		sub, ok := v["Fn::Sub"].(string)
+		if !ok || len(v) != 1 {
+			return "", false
+		}
+
+		if stackName != "" {
+			sub = strings.Replace(sub, "${AWS::StackName}", stackName, -1)
+		}
+
+		if strings.Contains(sub, "${") {
+			return "", false
+		}
+
+		return sub, true
+	}
+
+	return "", false
+}
+
+func sortedKeys(data interface{}) []string {
+	keys := make([]string, 0)
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]string:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,174 @@
+package manifest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/manifest"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+const source = `
+Stacks:
+  network:
+    Template: network.yaml
+    Region: eu-west-1
+    Parameters:
+      Cidr: 10.0.0.0/16
+      Count: 3
+  app:
+    Template: app.yaml
+    Parameters:
+      VpcId: ${network.VpcId}
+      Subnets: ${network.PublicSubnet},${network.PrivateSubnet}
+    Tags:
+      Team: web
+  database:
+    Template: database.yaml
+  monitoring:
+    Template: monitoring.yaml
+    DependsOn:
+      - app
+`
+
+func parseTemplate(t *testing.T, source string) cfn.Template {
+	template, err := parse.String(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return template
+}
+
+func TestParse(t *testing.T) {
+	m, err := manifest.Parse([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m.Names(), []string{"app", "database", "monitoring", "network"}) {
+		t.Errorf("Unexpected stacks: %v", m.Names())
+	}
+
+	network := m.Stacks["network"]
+	if network.Name != "network" || network.Region != "eu-west-1" || network.Parameters["Count"] != "3" {
+		t.Errorf("Unexpected stack: %+v", network)
+	}
+
+	if m.Stacks["app"].Tags["Team"] != "web" {
+		t.Errorf("Unexpected tags: %v", m.Stacks["app"].Tags)
+	}
+
+	expected := []manifest.Reference{
+		{"network", "PublicSubnet"},
+		{"network", "PrivateSubnet"},
+		{"network", "VpcId"},
+	}
+	if refs := m.Stacks["app"].References(); !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Unexpected references: %v", refs)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		"Stacks: {}\n",
+		"Stacks:\n  a:\n    Region: us-east-1\n",
+		"Stacks:\n  a:\n    Template: a.yaml\n    DependsOn:\n      - b\n",
+		"Stacks:\n  a:\n    Template: a.yaml\n    Parameters:\n      X: ${b.Output}\n",
+	}
+
+	for _, testCase := range cases {
+		if _, err := manifest.Parse([]byte(testCase)); err == nil {
+			t.Errorf("Expected an error for: %s", testCase)
+		}
+	}
+}
+
+func TestResolveParameters(t *testing.T) {
+	m, _ := manifest.Parse([]byte(source))
+
+	outputs := map[string]map[string]string{
+		"network": {
+			"VpcId":         "vpc-1",
+			"PublicSubnet":  "subnet-1",
+			"PrivateSubnet": "subnet-2",
+		},
+	}
+
+	values, err := m.Stacks["app"].ResolveParameters(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"VpcId":   "vpc-1",
+		"Subnets": "subnet-1,subnet-2",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Got %v, want %v", values, expected)
+	}
+
+	delete(outputs["network"], "VpcId")
+	if _, err := m.Stacks["app"].ResolveParameters(outputs); err == nil {
+		t.Error("Expected an error for a missing output")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	m, _ := manifest.Parse([]byte(source))
+
+	templates := map[string]cfn.Template{
+		"network": parseTemplate(t, `
+Outputs:
+  VpcId:
+    Value: vpc
+    Export:
+      Name:
+        Fn::Sub: ${AWS::StackName}-VpcId
+`),
+		"database": parseTemplate(t, `
+Resources:
+  Instance:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      VpcId:
+        Fn::ImportValue: network-VpcId
+`),
+	}
+
+	waves, err := m.Order(templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"network"},
+		{"app", "database"},
+		{"monitoring"},
+	}
+	if !reflect.DeepEqual(waves, expected) {
+		t.Errorf("Got %v, want %v", waves, expected)
+	}
+}
+
+func TestOrderCycle(t *testing.T) {
+	m, err := manifest.Parse([]byte(`
+Stacks:
+  a:
+    Template: a.yaml
+    DependsOn:
+      - b
+  b:
+    Template: b.yaml
+    Parameters:
+      X: ${a.Output}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Order(nil); err == nil {
+		t.Error("Expected an error for a dependency cycle")
+	}
+}
@@ -0,0 +1,138 @@
+// Package meta reads the deployment settings that a template
+// can carry in a Rain block in its Metadata section:
+//
+//	Metadata:
+//	  Rain:
+//	    StackName: my-stack
+//	    Region: eu-west-1
+//	    Capabilities:
+//	      - CAPABILITY_IAM
+//	    Parameters:
+//	      Name: Value
+//	    Tags:
+//	      Key: Value
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws-cloudformation/rain/cfn/params"
+)
+
+// Key is the name of the block in the template's Metadata section
+const Key = "Rain"
+
+var validCapabilities = []string{
+	capabilities.IAM,
+	capabilities.NamedIAM,
+	capabilities.AutoExpand,
+}
+
+// Settings holds the deployment settings read from a template
+type Settings struct {
+	StackName    string
+	Region       string
+	Capabilities []string
+	Parameters   map[string]string
+	Tags         map[string]string
+}
+
+// Read returns the settings in the template's Metadata.
+// If there are none, the Settings will be empty.
+func Read(t cfn.Template) (Settings, error) {
+	out := Settings{
+		Parameters: make(map[string]string),
+		Tags:       make(map[string]string),
+	}
+
+	metadata, _ := t["Metadata"].(map[string]interface{})
+	block, ok := metadata[Key]
+	if !ok {
+		return out, nil
+	}
+
+	j, err := json.Marshal(block)
+	if err != nil {
+		return out, fmt.Errorf("Invalid Metadata.%s: %s", Key, err)
+	}
+
+	var raw struct {
+		StackName    string
+		Region       string
+		Capabilities []string
+		Parameters   map[string]interface{}
+		Tags         map[string]interface{}
+	}
+
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.DisallowUnknownFields()
+
+	err = d.Decode(&raw)
+	if err != nil {
+		return out, fmt.Errorf("Invalid Metadata.%s: %s", Key, err)
+	}
+
+	for _, capability := range raw.Capabilities {
+		valid := false
+		for _, name := range validCapabilities {
+			if capability == name {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return out, fmt.Errorf("Invalid Metadata.%s: unknown capability '%s'", Key, capability)
+		}
+	}
+
+	out.StackName = raw.StackName
+	out.Region = raw.Region
+	out.Capabilities = raw.Capabilities
+
+	for key, value := range raw.Parameters {
+		out.Parameters[key] = params.Stringify(value)
+	}
+
+	for key, value := range raw.Tags {
+		out.Tags[key] = params.Stringify(value)
+	}
+
+	return out, nil
+}
+
+// Strip returns a copy of the template without its Rain settings.
+// The Metadata section is removed if nothing else is left in it.
+func Strip(t cfn.Template) cfn.Template {
+	metadata, ok := t["Metadata"].(map[string]interface{})
+	if !ok {
+		return t
+	}
+
+	if _, ok := metadata[Key]; !ok {
+		return t
+	}
+
+	out := make(cfn.Template, len(t))
+	for key, value := range t {
+		out[key] = value
+	}
+
+	rest := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		if key != Key {
+			rest[key] = value
+		}
+	}
+
+	if len(rest) == 0 {
+		delete(out, "Metadata")
+	} else {
+		out["Metadata"] = rest
+	}
+
+	return out
+}
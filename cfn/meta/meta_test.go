@@ -0,0 +1,105 @@
+package meta_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/meta"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+const source = `
+Metadata:
+  Rain:
+    StackName: my-stack
+    Region: eu-west-1
+    Capabilities:
+      - CAPABILITY_IAM
+    Parameters:
+      Name: test
+      Count: 3
+    Tags:
+      Team: web
+  Other: value
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`
+
+func parseTemplate(t *testing.T, source string) cfn.Template {
+	template, err := parse.String(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return template
+}
+
+func TestRead(t *testing.T) {
+	settings, err := meta.Read(parseTemplate(t, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := meta.Settings{
+		StackName:    "my-stack",
+		Region:       "eu-west-1",
+		Capabilities: []string{"CAPABILITY_IAM"},
+		Parameters: map[string]string{
+			"Name":  "test",
+			"Count": "3",
+		},
+		Tags: map[string]string{
+			"Team": "web",
+		},
+	}
+
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("Got %+v, want %+v", settings, expected)
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	settings, err := meta.Read(parseTemplate(t, "Resources: {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if settings.StackName != "" || len(settings.Parameters) != 0 {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	cases := []string{
+		"Metadata:\n  Rain:\n    Stack: my-stack\n",
+		"Metadata:\n  Rain:\n    Capabilities:\n      - CAPABILITY_EVERYTHING\n",
+		"Metadata:\n  Rain: my-stack\n",
+	}
+
+	for _, testCase := range cases {
+		if _, err := meta.Read(parseTemplate(t, testCase)); err == nil {
+			t.Errorf("Expected an error for: %s", testCase)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	template := parseTemplate(t, source)
+	stripped := meta.Strip(template)
+
+	expected := map[string]interface{}{"Other": "value"}
+	if !reflect.DeepEqual(stripped["Metadata"], expected) {
+		t.Errorf("Got %v, want %v", stripped["Metadata"], expected)
+	}
+
+	if _, ok := template["Metadata"].(map[string]interface{})[meta.Key]; !ok {
+		t.Error("Strip modified the original template")
+	}
+
+	stripped = meta.Strip(parseTemplate(t, "Metadata:\n  Rain:\n    StackName: test\nResources: {}\n"))
+	if _, ok := stripped["Metadata"]; ok {
+		t.Errorf("Expected empty Metadata to be removed: %v", stripped)
+	}
+}
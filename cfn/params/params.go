@@ -0,0 +1,257 @@
+// Package params reads values for CloudFormation parameters from files
+// and validates them against the constraints set in a cfn.Template
+package params
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws-cloudformation/rain/cfn"
+
+	yamlwrapper "github.com/sanathkr/yaml"
+)
+
+// File holds the parameter values and tags read from a parameters file
+type File struct {
+	Parameters map[string]string
+	Tags       map[string]string
+}
+
+// Parse reads parameter values from data, which may be in any of the following formats:
+//
+// A CloudFormation parameters file, as used by the AWS CLI:
+//
+//	[{"ParameterKey": "Name", "ParameterValue": "Value"}]
+//
+// A CodePipeline template configuration file:
+//
+//	{"Parameters": {"Name": "Value"}, "Tags": {"Key": "Value"}}
+//
+// A plain map of parameter names to values, in JSON or YAML:
+//
+//	Name: Value
+func Parse(data []byte) (File, error) {
+	out := File{
+		Parameters: make(map[string]string),
+		Tags:       make(map[string]string),
+	}
+
+	j, err := yamlwrapper.YAMLToJSON(data)
+	if err != nil {
+		return out, fmt.Errorf("Invalid YAML: %s", err)
+	}
+
+	var raw interface{}
+	err = json.Unmarshal(j, &raw)
+	if err != nil {
+		return out, fmt.Errorf("Invalid JSON: %s", err)
+	}
+
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			param, ok := item.(map[string]interface{})
+			if !ok {
+				return out, errors.New("Parameter list entries must contain ParameterKey and ParameterValue")
+			}
+
+			key, ok := param["ParameterKey"].(string)
+			if !ok {
+				return out, errors.New("Parameter list entry has no ParameterKey")
+			}
+
+			if _, ok := param["ParameterValue"]; !ok {
+				return out, fmt.Errorf("Parameter '%s' has no ParameterValue", key)
+			}
+
+			out.Parameters[key] = Stringify(param["ParameterValue"])
+		}
+	case map[string]interface{}:
+		if p, ok := v["Parameters"].(map[string]interface{}); ok {
+			// CodePipeline template configuration
+			for key, value := range p {
+				out.Parameters[key] = Stringify(value)
+			}
+
+			if tags, ok := v["Tags"].(map[string]interface{}); ok {
+				for key, value := range tags {
+					out.Tags[key] = Stringify(value)
+				}
+			}
+		} else {
+			for key, value := range v {
+				out.Parameters[key] = Stringify(value)
+			}
+		}
+	case nil:
+	default:
+		return out, errors.New("Parameters must be a list or a map")
+	}
+
+	return out, nil
+}
+
+// Stringify returns the string form of a parameter value.
+// Lists are joined with commas as expected by CommaDelimitedList parameters.
+func Stringify(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(value))
+		for i, part := range value {
+			parts[i] = Stringify(part)
+		}
+		return strings.Join(parts, ",")
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+// Names returns the names of the parameters declared in a template, sorted alphabetically
+func Names(t cfn.Template) []string {
+	params, _ := t["Parameters"].(map[string]interface{})
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Default returns the default value of the named parameter
+// and whether it has one
+func Default(t cfn.Template, name string) (string, bool) {
+	params, _ := t["Parameters"].(map[string]interface{})
+	param, _ := params[name].(map[string]interface{})
+
+	value, ok := param["Default"]
+	if !ok {
+		return "", false
+	}
+
+	return Stringify(value), true
+}
+
+// Validate checks that value satisfies the constraints of the named parameter in the template
+func Validate(t cfn.Template, name, value string) error {
+	params, _ := t["Parameters"].(map[string]interface{})
+
+	param, ok := params[name].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Parameter '%s' is not defined in the template", name)
+	}
+
+	err := validate(param, value)
+	if err != nil {
+		if desc, ok := param["ConstraintDescription"].(string); ok {
+			return fmt.Errorf("Invalid value for parameter '%s': %s", name, desc)
+		}
+
+		return fmt.Errorf("Invalid value for parameter '%s': %s", name, err)
+	}
+
+	return nil
+}
+
+func validate(param map[string]interface{}, value string) error {
+	typeName, _ := param["Type"].(string)
+
+	values := []string{value}
+	if typeName == "CommaDelimitedList" || strings.HasPrefix(typeName, "List<") {
+		values = strings.Split(value, ",")
+	}
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+
+		if typeName == "Number" || typeName == "List<Number>" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("'%s' is not a number", v)
+			}
+
+			if min, ok := number(param["MinValue"]); ok && n < min {
+				return fmt.Errorf("%s is less than the minimum of %s", v, Stringify(param["MinValue"]))
+			}
+
+			if max, ok := number(param["MaxValue"]); ok && n > max {
+				return fmt.Errorf("%s is greater than the maximum of %s", v, Stringify(param["MaxValue"]))
+			}
+		}
+	}
+
+	if allowed, ok := param["AllowedValues"].([]interface{}); ok {
+		for _, v := range values {
+			found := false
+			for _, a := range allowed {
+				if strings.TrimSpace(v) == Stringify(a) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				options := make([]string, len(allowed))
+				for i, a := range allowed {
+					options[i] = Stringify(a)
+				}
+
+				return fmt.Errorf("'%s' is not one of: %s", v, strings.Join(options, ", "))
+			}
+		}
+	}
+
+	if pattern, ok := param["AllowedPattern"].(string); ok {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("AllowedPattern '%s' is invalid: %s", pattern, err)
+		}
+
+		// Each element of a list parameter must match the pattern
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+
+			if !re.MatchString(v) {
+				return fmt.Errorf("'%s' does not match the pattern '%s'", v, pattern)
+			}
+		}
+	}
+
+	length := float64(utf8.RuneCountInString(value))
+
+	if min, ok := number(param["MinLength"]); ok && length < min {
+		return fmt.Errorf("Value must be at least %s characters long", Stringify(param["MinLength"]))
+	}
+
+	if max, ok := number(param["MaxLength"]); ok && length > max {
+		return fmt.Errorf("Value must be at most %s characters long", Stringify(param["MaxLength"]))
+	}
+
+	return nil
+}
+
+// number returns v as a float64; template values may be numbers or strings
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
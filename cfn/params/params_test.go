@@ -0,0 +1,121 @@
+package params_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/params"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+func TestParse(t *testing.T) {
+	cases := []string{
+		`[{"ParameterKey": "Name", "ParameterValue": "foo"}, {"ParameterKey": "Count", "ParameterValue": "3"}]`,
+		`{"Parameters": {"Name": "foo", "Count": 3}, "Tags": {"Owner": "me"}}`,
+		"Name: foo\nCount: 3\n",
+	}
+
+	expectedTags := []map[string]string{
+		{},
+		{"Owner": "me"},
+		{},
+	}
+
+	for i, testCase := range cases {
+		actual, err := params.Parse([]byte(testCase))
+		if err != nil {
+			t.Errorf("%s: %s", testCase, err)
+			continue
+		}
+
+		expected := map[string]string{"Name": "foo", "Count": "3"}
+		if !reflect.DeepEqual(actual.Parameters, expected) {
+			t.Errorf("%s: got %v, want %v", testCase, actual.Parameters, expected)
+		}
+
+		if !reflect.DeepEqual(actual.Tags, expectedTags[i]) {
+			t.Errorf("%s: got tags %v, want %v", testCase, actual.Tags, expectedTags[i])
+		}
+	}
+
+	for _, testCase := range []string{`"foo"`, `[{"ParameterValue": "foo"}]`, `[{"ParameterKey": "foo"}]`} {
+		if _, err := params.Parse([]byte(testCase)); err == nil {
+			t.Errorf("Expected an error from %s", testCase)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	template, err := parse.String(`
+Parameters:
+  Env:
+    Type: String
+    AllowedValues: [dev, prod]
+  Name:
+    Type: String
+    AllowedPattern: "[a-z]+"
+    MinLength: 2
+    MaxLength: 5
+  Size:
+    Type: Number
+    MinValue: 1
+    MaxValue: 10
+    Default: 5
+  Zones:
+    Type: CommaDelimitedList
+    AllowedValues: [a, b, c]
+  Code:
+    Type: String
+    AllowedPattern: "[0-9]+"
+    ConstraintDescription: must be numeric
+  Subnets:
+    Type: CommaDelimitedList
+    AllowedPattern: "subnet-[0-9a-f]+"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"Env", "dev", true},
+		{"Env", "test", false},
+		{"Name", "abc", true},
+		{"Name", "ABC", false},
+		{"Name", "abcabc", false},
+		{"Name", "a", false},
+		{"Size", "10", true},
+		{"Size", "11", false},
+		{"Size", "0.5", false},
+		{"Size", "five", false},
+		{"Zones", "a,b", true},
+		{"Zones", "a,d", false},
+		{"Subnets", "subnet-1a", true},
+		{"Subnets", "subnet-1a, subnet-2b", true},
+		{"Subnets", "subnet-1a,vpc-2b", false},
+		{"Missing", "value", false},
+	}
+
+	for _, testCase := range cases {
+		err := params.Validate(template, testCase.name, testCase.value)
+		if (err == nil) != testCase.valid {
+			t.Errorf("%s=%s: got %v, want valid=%t", testCase.name, testCase.value, err, testCase.valid)
+		}
+	}
+
+	err = params.Validate(template, "Code", "abc")
+	if err == nil || err.Error() != "Invalid value for parameter 'Code': must be numeric" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if value, ok := params.Default(template, "Size"); !ok || value != "5" {
+		t.Errorf("Unexpected default: %s, %t", value, ok)
+	}
+
+	if !reflect.DeepEqual(params.Names(template), []string{"Code", "Env", "Name", "Size", "Subnets", "Zones"}) {
+		t.Errorf("Unexpected names: %v", params.Names(template))
+	}
+}
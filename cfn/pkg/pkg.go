@@ -0,0 +1,339 @@
+// Package pkg packages a cfn.Template for deployment by uploading
+// any local files that it references and rewriting the template
+// to refer to the uploaded copies
+package pkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	cfnformat "github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+// Store is somewhere that artifacts can be uploaded to, such as an S3 bucket
+type Store interface {
+	// Bucket returns the name of the bucket that artifacts are stored in
+	Bucket() string
+
+	// URL returns an HTTPS URL for the object stored with the given key
+	URL(key string) string
+
+	// Exists returns true if an object is already stored with the given key
+	Exists(key string) bool
+
+	// Put stores data with the given key
+	Put(key string, data []byte) error
+}
+
+// format describes how a property refers to an uploaded artifact
+type format int
+
+const (
+	// s3URI is a string of the form s3://bucket/key
+	s3URI format = iota
+
+	// s3Object is a map with S3Bucket and S3Key properties
+	s3Object
+
+	// bucketKey is a map with Bucket and Key properties
+	bucketKey
+
+	// httpsURL is an HTTPS URL for the object
+	httpsURL
+)
+
+// artifact describes a resource property that can refer to a local path
+type artifact struct {
+	// path is the location of the property within the resource's Properties
+	path []string
+
+	// format is how the property should refer to the uploaded artifact
+	format format
+
+	// zip is true if directories (and single files) must be zipped before upload
+	zip bool
+
+	// template is true if the artifact is a nested template,
+	// which must be packaged itself before it is uploaded
+	template bool
+}
+
+// artifacts lists the resource properties that can refer to local paths
+var artifacts = map[string][]artifact{
+	"AWS::ApiGateway::RestApi":                  {{[]string{"BodyS3Location"}, bucketKey, false, false}},
+	"AWS::AppSync::GraphQLSchema":               {{[]string{"DefinitionS3Location"}, s3URI, false, false}},
+	"AWS::AppSync::Resolver":                    {{[]string{"RequestMappingTemplateS3Location"}, s3URI, false, false}, {[]string{"ResponseMappingTemplateS3Location"}, s3URI, false, false}},
+	"AWS::CloudFormation::Stack":                {{[]string{"TemplateURL"}, httpsURL, false, true}},
+	"AWS::ElasticBeanstalk::ApplicationVersion": {{[]string{"SourceBundle"}, s3Object, true, false}},
+	"AWS::Glue::Job":                            {{[]string{"Command", "ScriptLocation"}, s3URI, false, false}},
+	"AWS::Lambda::Function":                     {{[]string{"Code"}, s3Object, true, false}},
+	"AWS::Lambda::LayerVersion":                 {{[]string{"Content"}, s3Object, true, false}},
+	"AWS::Serverless::Api":                      {{[]string{"DefinitionUri"}, s3URI, false, false}},
+	"AWS::Serverless::Application":              {{[]string{"Location"}, httpsURL, false, true}},
+	"AWS::Serverless::Function":                 {{[]string{"CodeUri"}, s3URI, true, false}},
+	"AWS::Serverless::HttpApi":                  {{[]string{"DefinitionUri"}, s3URI, false, false}},
+	"AWS::Serverless::LayerVersion":             {{[]string{"ContentUri"}, s3URI, true, false}},
+	"AWS::Serverless::StateMachine":             {{[]string{"DefinitionUri"}, s3URI, false, false}},
+	"AWS::StepFunctions::StateMachine":          {{[]string{"DefinitionS3Location"}, bucketKey, false, false}},
+}
+
+// Template uploads any local files referenced by the template to store
+// and rewrites the template to refer to the uploaded copies.
+// Relative paths are resolved from baseDir, which should be
+// the directory that contains the template.
+//
+// The template is modified in place and also returned for convenience.
+func Template(t cfn.Template, baseDir string, store Store) (cfn.Template, error) {
+	return packageTemplate(t, baseDir, store, nil)
+}
+
+// LocalPaths returns the sorted local paths referred to by the template
+// that Template would upload. Nested templates are not searched.
+func LocalPaths(t cfn.Template) []string {
+	paths := make([]string, 0)
+
+	findLocal(t, func(ref localRef) error {
+		paths = append(paths, ref.parent[ref.key].(string))
+		return nil
+	})
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// localRef is a property that refers to a local path
+type localRef struct {
+	// resource is the name of the resource that contains the property,
+	// or empty for the location of an AWS::Include transform
+	resource string
+
+	artifact artifact
+
+	// parent is the map that contains the property and key is its name
+	parent map[string]interface{}
+	key    string
+}
+
+// findLocal calls fn for each property in t that refers to a local path,
+// stopping at the first error
+func findLocal(t cfn.Template, fn func(localRef) error) error {
+	resources, _ := t["Resources"].(map[string]interface{})
+
+	for name, r := range resources {
+		resource, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		typeName, _ := resource["Type"].(string)
+		props, ok := resource["Properties"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, a := range artifacts[typeName] {
+			// Find the map that contains the property
+			parent := props
+			for _, part := range a.path[:len(a.path)-1] {
+				parent, ok = parent[part].(map[string]interface{})
+				if !ok {
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+
+			key := a.path[len(a.path)-1]
+			localPath, ok := parent[key].(string)
+			if !ok || !isLocal(localPath) {
+				continue
+			}
+
+			if err := fn(localRef{name, a, parent, key}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// AWS::Include transforms can appear anywhere
+	var err error
+	walk(t.Map(), func(m map[string]interface{}) {
+		if err != nil || m["Name"] != "AWS::Include" {
+			return
+		}
+
+		params, ok := m["Parameters"].(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		location, ok := params["Location"].(string)
+		if !ok || !isLocal(location) {
+			return
+		}
+
+		err = fn(localRef{"", artifact{format: s3URI}, params, "Location"})
+	})
+
+	return err
+}
+
+// packageTemplate packages t. parents lists the paths of the templates
+// that t is nested within so that cycles can be detected.
+func packageTemplate(t cfn.Template, baseDir string, store Store, parents []string) (cfn.Template, error) {
+	err := findLocal(t, func(ref localRef) error {
+		localPath := ref.parent[ref.key].(string)
+
+		value, err := upload(resolve(baseDir, localPath), ref.artifact, store, parents)
+		if err != nil {
+			if ref.resource == "" {
+				return fmt.Errorf("Unable to package AWS::Include location '%s': %s", localPath, err)
+			}
+
+			return fmt.Errorf("Unable to package %s of resource '%s': %s", strings.Join(ref.artifact.path, "."), ref.resource, err)
+		}
+
+		ref.parent[ref.key] = value
+
+		return nil
+	})
+
+	return t, err
+}
+
+// isLocal returns true if path looks like a local file path rather than a URL
+func isLocal(path string) bool {
+	if path == "" {
+		return false
+	}
+
+	for _, prefix := range []string{"s3://", "http://", "https://"} {
+		if strings.HasPrefix(strings.ToLower(path), prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resolve(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(baseDir, path)
+}
+
+// upload stores the artifact found at path
+// and returns the value that should replace the property
+func upload(path string, a artifact, store Store, parents []string) (interface{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	ext := filepath.Ext(path)
+
+	switch {
+	case a.template && !info.IsDir():
+		data, err = nestedTemplate(path, store, parents)
+	case info.IsDir():
+		if !a.zip {
+			return nil, fmt.Errorf("'%s' is a directory", path)
+		}
+
+		data, err = Zip(path)
+		ext = ".zip"
+	case a.zip && ext != ".zip" && ext != ".jar":
+		data, err = Zip(path)
+		ext = ".zip"
+	default:
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Artifacts are stored by the hash of their contents
+	// so unchanged artifacts are only ever uploaded once
+	key := fmt.Sprintf("%x%s", sha256.Sum256(data), ext)
+
+	if !store.Exists(key) {
+		err = store.Put(key, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reference(store, key, a.format), nil
+}
+
+// nestedTemplate packages the template at path and returns its formatted contents
+func nestedTemplate(path string, store Store, parents []string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, parent := range parents {
+		if parent == abs {
+			return nil, fmt.Errorf("'%s' includes itself", path)
+		}
+	}
+
+	t, err := parse.File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = packageTemplate(t, filepath.Dir(path), store, append(parents, abs))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to package nested template '%s': %s", path, err)
+	}
+
+	return []byte(cfnformat.Template(t, cfnformat.Options{})), nil
+}
+
+// reference returns the value that refers to key in the format required by a property
+func reference(store Store, key string, f format) interface{} {
+	switch f {
+	case s3Object:
+		return map[string]interface{}{
+			"S3Bucket": store.Bucket(),
+			"S3Key":    key,
+		}
+	case bucketKey:
+		return map[string]interface{}{
+			"Bucket": store.Bucket(),
+			"Key":    key,
+		}
+	case httpsURL:
+		return store.URL(key)
+	default:
+		return fmt.Sprintf("s3://%s/%s", store.Bucket(), key)
+	}
+}
+
+func walk(data interface{}, fn func(map[string]interface{})) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		fn(v)
+		for _, child := range v {
+			walk(child, fn)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walk(child, fn)
+		}
+	}
+}
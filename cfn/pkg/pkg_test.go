@@ -0,0 +1,377 @@
+package pkg_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/cfn/pkg"
+)
+
+// memStore is an in-memory stand-in for an S3 bucket
+type memStore struct {
+	name    string
+	objects map[string][]byte
+	puts    int
+}
+
+func (s *memStore) Bucket() string {
+	if s.name != "" {
+		return s.name
+	}
+
+	return "bucket"
+}
+
+func (s *memStore) URL(key string) string {
+	return "https://" + s.Bucket() + ".s3.amazonaws.com/" + key
+}
+
+func (s *memStore) Exists(key string) bool {
+	_, ok := s.objects[key]
+	return ok
+}
+
+func (s *memStore) Put(key string, data []byte) error {
+	s.objects[key] = data
+	s.puts++
+	return nil
+}
+
+func setup(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rain-pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"src/index.py":     "def handler(event, context):\n    pass\n",
+		"src/lib/util.py":  "x = 1\n",
+		"child.yaml":       "Resources: {}\n",
+		"include.yaml":     "Type: AWS::S3::Bucket\n",
+		"api/swagger.yaml": "swagger: '2.0'\n",
+	}
+
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(fn), 0755)
+		if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestTemplate(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	template, err := parse.String(`
+Resources:
+  Sam:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: src
+  Lambda:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code: ./src
+  Remote:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: s3://elsewhere/code.zip
+  Api:
+    Type: AWS::Serverless::Api
+    Properties:
+      DefinitionUri: api/swagger.yaml
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: child.yaml
+  Included:
+    Fn::Transform:
+      Name: AWS::Include
+      Parameters:
+        Location: include.yaml
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &memStore{objects: make(map[string][]byte)}
+
+	_, err = pkg.Template(template, dir, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := template["Resources"].(map[string]interface{})
+	props := func(name string) map[string]interface{} {
+		return resources[name].(map[string]interface{})["Properties"].(map[string]interface{})
+	}
+
+	samURI := props("Sam")["CodeUri"].(string)
+	if !strings.HasPrefix(samURI, "s3://bucket/") || !strings.HasSuffix(samURI, ".zip") {
+		t.Errorf("Unexpected CodeUri: %s", samURI)
+	}
+
+	code := props("Lambda")["Code"].(map[string]interface{})
+	if code["S3Bucket"] != "bucket" || "s3://bucket/"+code["S3Key"].(string) != samURI {
+		t.Errorf("Unexpected Code: %v", code)
+	}
+
+	if props("Remote")["CodeUri"] != "s3://elsewhere/code.zip" {
+		t.Errorf("Remote CodeUri should not change: %v", props("Remote")["CodeUri"])
+	}
+
+	if !strings.HasSuffix(props("Api")["DefinitionUri"].(string), ".yaml") {
+		t.Errorf("Unexpected DefinitionUri: %v", props("Api")["DefinitionUri"])
+	}
+
+	if !strings.HasPrefix(props("Child")["TemplateURL"].(string), "https://bucket.s3.amazonaws.com/") {
+		t.Errorf("Unexpected TemplateURL: %v", props("Child")["TemplateURL"])
+	}
+
+	location := resources["Included"].(map[string]interface{})["Fn::Transform"].(map[string]interface{})["Parameters"].(map[string]interface{})["Location"]
+	if !strings.HasPrefix(location.(string), "s3://bucket/") {
+		t.Errorf("Unexpected Location: %v", location)
+	}
+
+	// One zip (shared), swagger, child, include
+	if len(store.objects) != 4 {
+		t.Errorf("Expected 4 uploads, got %d", len(store.objects))
+	}
+}
+
+func TestMissingFile(t *testing.T) {
+	template, _ := parse.String(`
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: does-not-exist
+`)
+
+	_, err := pkg.Template(template, os.TempDir(), &memStore{objects: make(map[string][]byte)})
+	if err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+}
+
+func TestZipIsDeterministic(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	first, err := pkg.Zip(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "src", "index.py"), later, later)
+
+	second, err := pkg.Zip(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Error("Zip output changed when only timestamps changed")
+	}
+}
+
+func TestSkipExisting(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	source := `
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: src
+`
+
+	store := &memStore{objects: make(map[string][]byte)}
+
+	packageTemplate := func() string {
+		template, _ := parse.String(source)
+		_, err := pkg.Template(template, dir, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return template["Resources"].(map[string]interface{})["Function"].(map[string]interface{})["Properties"].(map[string]interface{})["CodeUri"].(string)
+	}
+
+	first := packageTemplate()
+
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "src", "lib", "util.py"), later, later)
+
+	second := packageTemplate()
+
+	if first != second {
+		t.Errorf("CodeUri changed when only timestamps changed: %s != %s", first, second)
+	}
+
+	if store.puts != 1 {
+		t.Errorf("Expected 1 upload, got %d", store.puts)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "src", "index.py"), []byte("changed\n"), 0644)
+
+	if packageTemplate() == first {
+		t.Error("CodeUri should change when the code changes")
+	}
+
+	if store.puts != 2 {
+		t.Errorf("Expected 2 uploads, got %d", store.puts)
+	}
+}
+
+func TestNestedTemplate(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "nested"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "nested", "child.yaml"), []byte(`
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: ../src
+`), 0644)
+
+	template, _ := parse.String(`
+Resources:
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: nested/child.yaml
+`)
+
+	store := &memStore{objects: make(map[string][]byte)}
+
+	_, err := pkg.Template(template, dir, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := template["Resources"].(map[string]interface{})["Child"].(map[string]interface{})["Properties"].(map[string]interface{})["TemplateURL"].(string)
+	key := strings.TrimPrefix(url, "https://bucket.s3.amazonaws.com/")
+
+	child, err := parse.String(string(store.objects[key]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codeURI := child["Resources"].(map[string]interface{})["Function"].(map[string]interface{})["Properties"].(map[string]interface{})["CodeUri"].(string)
+	if !strings.HasPrefix(codeURI, "s3://bucket/") {
+		t.Errorf("Nested template was not packaged: CodeUri is %s", codeURI)
+	}
+
+	if len(store.objects) != 2 {
+		t.Errorf("Expected 2 uploads, got %d", len(store.objects))
+	}
+}
+
+func TestNestedTemplateCycle(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "loop.yaml"), []byte(`
+Resources:
+  Self:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: loop.yaml
+`), 0644)
+
+	template, _ := parse.File(filepath.Join(dir, "loop.yaml"))
+
+	_, err := pkg.Template(template, dir, &memStore{objects: make(map[string][]byte)})
+	if err == nil {
+		t.Error("Expected an error for a template that includes itself")
+	}
+}
+
+func TestTemplatePerStore(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	source, _ := parse.String(`
+Resources:
+  Function:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code: src
+`)
+
+	bucketOf := func(name string) string {
+		packaged, err := pkg.Template(source.Copy(), dir, &memStore{name: name, objects: make(map[string][]byte)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code := packaged["Resources"].(map[string]interface{})["Function"].(map[string]interface{})["Properties"].(map[string]interface{})["Code"]
+		return code.(map[string]interface{})["S3Bucket"].(string)
+	}
+
+	for _, name := range []string{"bucket-us-east-1", "bucket-eu-west-1"} {
+		if bucket := bucketOf(name); bucket != name {
+			t.Errorf("Expected code in %s, got %s", name, bucket)
+		}
+	}
+
+	if code := source["Resources"].(map[string]interface{})["Function"].(map[string]interface{})["Properties"].(map[string]interface{})["Code"]; code != "src" {
+		t.Errorf("Source template was modified: %v", code)
+	}
+}
+
+func TestLocalPaths(t *testing.T) {
+	template, _ := parse.String(`
+Resources:
+  Function:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code: src
+  Remote:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: s3://elsewhere/code.zip
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: child.yaml
+  Included:
+    Fn::Transform:
+      Name: AWS::Include
+      Parameters:
+        Location: include.yaml
+`)
+
+	expected := []string{"child.yaml", "include.yaml", "src"}
+	if actual := pkg.LocalPaths(template); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	remote, _ := parse.String(`
+Resources:
+  Remote:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: s3://elsewhere/code.zip
+`)
+
+	if actual := pkg.LocalPaths(remote); len(actual) != 0 {
+		t.Errorf("Expected no local paths, got %v", actual)
+	}
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// zipTime is used as the modification time of every file in a zip
+// so that zipping the same files always produces the same output
+var zipTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Zip returns a zip archive of the file or directory at path.
+// The output depends only on the names, contents, and permissions of the files
+// so zipping unchanged files always produces identical output.
+func Zip(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+
+	if info.IsDir() {
+		err = filepath.Walk(path, func(fn string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(path, fn)
+			if err != nil {
+				return err
+			}
+
+			files[filepath.ToSlash(rel)] = fn
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files[info.Name()] = path
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bytes.Buffer{}
+	w := zip.NewWriter(&buf)
+
+	for _, name := range names {
+		fn := files[name]
+
+		info, err := os.Stat(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: zipTime,
+		}
+
+		// Only keep the executable bit
+		if info.Mode()&0111 != 0 {
+			header.SetMode(0755)
+		} else {
+			header.SetMode(0644)
+		}
+
+		f, err := w.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = f.Write(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// specURL is the CloudFormation resource specification for us-east-1
+const specURL = "https://d1uauaxba7bl26.cloudfront.net/latest/gzip/CloudFormationResourceSpecification.json"
+
+// The SAM specification isn't published in a machine-readable form,
+// so the tag properties of its resource types are listed here
+var samTags = map[string]string{
+	"AWS::Serverless::Api":          "MapTags",
+	"AWS::Serverless::Application":  "MapTags",
+	"AWS::Serverless::Function":     "MapTags",
+	"AWS::Serverless::HttpApi":      "MapTags",
+	"AWS::Serverless::SimpleTable":  "MapTags",
+	"AWS::Serverless::StateMachine": "MapTags",
+}
+
+type property struct {
+	Type              string
+	ItemType          string
+	PrimitiveType     string
+	PrimitiveItemType string
+}
+
+type spec struct {
+	PropertyTypes map[string]struct {
+		Properties map[string]property
+	}
+	ResourceTypes map[string]struct {
+		Properties map[string]property
+	}
+}
+
+// readSpec reads the specification from the file given on the command line,
+// or downloads it if no file was given
+func readSpec() spec {
+	var r io.Reader
+
+	if len(os.Args) > 1 {
+		f, err := os.Open(os.Args[1])
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+
+		r = f
+	} else {
+		resp, err := http.Get(specURL)
+		if err != nil {
+			panic(err)
+		}
+		defer resp.Body.Close()
+
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			panic(err)
+		}
+
+		r = gz
+	}
+
+	var s spec
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// tagFormat returns the format of a resource type's property
+// if it looks like it holds the resource's tags
+func tagFormat(s spec, resourceType, name string, p property) string {
+	if name != "Tags" && !strings.HasSuffix(name, "Tags") {
+		return ""
+	}
+
+	switch {
+	case p.PrimitiveType == "Json":
+		return "MapTags"
+	case p.Type == "Map" && p.PrimitiveItemType == "String":
+		return "MapTags"
+	case p.Type == "List" && p.ItemType == "Tag":
+		return "ListTags"
+	case p.Type == "List" && p.ItemType != "":
+		item := s.PropertyTypes[resourceType+"."+p.ItemType].Properties
+
+		_, hasKey := item["Key"]
+		_, hasValue := item["Value"]
+		if !hasKey || !hasValue {
+			return ""
+		}
+
+		if _, ok := item["PropagateAtLaunch"]; ok {
+			return "PropagatingListTags"
+		}
+
+		return "ListTags"
+	}
+
+	return ""
+}
+
+func main() {
+	s := readSpec()
+
+	properties := make(map[string]string)
+
+	for resourceType, r := range s.ResourceTypes {
+		names := make([]string, 0, len(r.Properties))
+		for name := range r.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			f := tagFormat(s, resourceType, name, r.Properties[name])
+			if f == "" {
+				continue
+			}
+
+			// Prefer a property that is simply called Tags
+			if _, ok := properties[resourceType]; !ok || name == "Tags" {
+				properties[resourceType] = fmt.Sprintf("{%q, %s}", name, f)
+			}
+		}
+	}
+
+	for resourceType, f := range samTags {
+		properties[resourceType] = fmt.Sprintf("{%q, %s}", "Tags", f)
+	}
+
+	resourceTypes := make([]string, 0, len(properties))
+	for resourceType := range properties {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	output := strings.Builder{}
+
+	output.WriteString(`package spec
+
+// Code generated. DO NOT EDIT.
+
+// tagProperties lists the resource types that can be tagged
+var tagProperties = map[string]tagProperty{
+`)
+
+	for _, resourceType := range resourceTypes {
+		output.WriteString(fmt.Sprintf("%q: %s,\n", resourceType, properties[resourceType]))
+	}
+
+	output.WriteString("}\n")
+
+	source, err := format.Source([]byte(output.String()))
+	if err != nil {
+		panic(err)
+	}
+
+	if err := ioutil.WriteFile("tag_properties.go", source, 0644); err != nil {
+		panic(err)
+	}
+}
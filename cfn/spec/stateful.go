@@ -0,0 +1,22 @@
+package spec
+
+// statefulTypes are resource types that hold data that would be lost if they were deleted
+var statefulTypes = map[string]bool{
+	"AWS::DocDB::DBCluster":              true,
+	"AWS::DynamoDB::Table":               true,
+	"AWS::EFS::FileSystem":               true,
+	"AWS::ElastiCache::ReplicationGroup": true,
+	"AWS::Elasticsearch::Domain":         true,
+	"AWS::Neptune::DBCluster":            true,
+	"AWS::RDS::DBCluster":                true,
+	"AWS::RDS::DBInstance":               true,
+	"AWS::Redshift::Cluster":             true,
+	"AWS::S3::Bucket":                    true,
+	"AWS::Serverless::SimpleTable":       true,
+}
+
+// IsStateful returns true if resources of the named type hold data
+// that would be lost if the resource were deleted or replaced
+func IsStateful(resourceType string) bool {
+	return statefulTypes[resourceType]
+}
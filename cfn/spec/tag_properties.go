@@ -0,0 +1,116 @@
+package spec
+
+// tagProperties lists the resource types that can be tagged.
+// It is maintained by hand; generate/main.go can rebuild it from the resource specification.
+var tagProperties = map[string]tagProperty{
+	"AWS::ACMPCA::CertificateAuthority":          {"Tags", ListTags},
+	"AWS::AmazonMQ::Broker":                      {"Tags", ListTags},
+	"AWS::ApiGateway::ApiKey":                    {"Tags", ListTags},
+	"AWS::ApiGateway::ClientCertificate":         {"Tags", ListTags},
+	"AWS::ApiGateway::DomainName":                {"Tags", ListTags},
+	"AWS::ApiGateway::RestApi":                   {"Tags", ListTags},
+	"AWS::ApiGateway::Stage":                     {"Tags", ListTags},
+	"AWS::ApiGateway::UsagePlan":                 {"Tags", ListTags},
+	"AWS::ApiGatewayV2::Api":                     {"Tags", MapTags},
+	"AWS::ApiGatewayV2::DomainName":              {"Tags", MapTags},
+	"AWS::ApiGatewayV2::Stage":                   {"Tags", MapTags},
+	"AWS::AppSync::GraphQLApi":                   {"Tags", ListTags},
+	"AWS::AutoScaling::AutoScalingGroup":         {"Tags", PropagatingListTags},
+	"AWS::Batch::ComputeEnvironment":             {"Tags", MapTags},
+	"AWS::Batch::JobDefinition":                  {"Tags", MapTags},
+	"AWS::Batch::JobQueue":                       {"Tags", MapTags},
+	"AWS::CertificateManager::Certificate":       {"Tags", ListTags},
+	"AWS::Cloud9::EnvironmentEC2":                {"Tags", ListTags},
+	"AWS::CloudFormation::Stack":                 {"Tags", ListTags},
+	"AWS::CloudFront::Distribution":              {"Tags", ListTags},
+	"AWS::CloudTrail::Trail":                     {"Tags", ListTags},
+	"AWS::CodeBuild::Project":                    {"Tags", ListTags},
+	"AWS::CodeCommit::Repository":                {"Tags", ListTags},
+	"AWS::CodePipeline::Pipeline":                {"Tags", ListTags},
+	"AWS::Cognito::UserPool":                     {"UserPoolTags", MapTags},
+	"AWS::DMS::Endpoint":                         {"Tags", ListTags},
+	"AWS::DMS::ReplicationInstance":              {"Tags", ListTags},
+	"AWS::DocDB::DBCluster":                      {"Tags", ListTags},
+	"AWS::DocDB::DBInstance":                     {"Tags", ListTags},
+	"AWS::DynamoDB::Table":                       {"Tags", ListTags},
+	"AWS::EC2::CustomerGateway":                  {"Tags", ListTags},
+	"AWS::EC2::EIP":                              {"Tags", ListTags},
+	"AWS::EC2::Instance":                         {"Tags", ListTags},
+	"AWS::EC2::InternetGateway":                  {"Tags", ListTags},
+	"AWS::EC2::NatGateway":                       {"Tags", ListTags},
+	"AWS::EC2::NetworkAcl":                       {"Tags", ListTags},
+	"AWS::EC2::NetworkInterface":                 {"Tags", ListTags},
+	"AWS::EC2::RouteTable":                       {"Tags", ListTags},
+	"AWS::EC2::SecurityGroup":                    {"Tags", ListTags},
+	"AWS::EC2::Subnet":                           {"Tags", ListTags},
+	"AWS::EC2::TransitGateway":                   {"Tags", ListTags},
+	"AWS::EC2::VPC":                              {"Tags", ListTags},
+	"AWS::EC2::VPNGateway":                       {"Tags", ListTags},
+	"AWS::EC2::Volume":                           {"Tags", ListTags},
+	"AWS::ECR::Repository":                       {"Tags", ListTags},
+	"AWS::ECS::Cluster":                          {"Tags", ListTags},
+	"AWS::ECS::Service":                          {"Tags", ListTags},
+	"AWS::ECS::TaskDefinition":                   {"Tags", ListTags},
+	"AWS::EFS::AccessPoint":                      {"AccessPointTags", ListTags},
+	"AWS::EFS::FileSystem":                       {"FileSystemTags", ListTags},
+	"AWS::EKS::Cluster":                          {"Tags", ListTags},
+	"AWS::EKS::Nodegroup":                        {"Tags", MapTags},
+	"AWS::EMR::Cluster":                          {"Tags", ListTags},
+	"AWS::ElastiCache::CacheCluster":             {"Tags", ListTags},
+	"AWS::ElastiCache::ReplicationGroup":         {"Tags", ListTags},
+	"AWS::ElasticBeanstalk::Environment":         {"Tags", ListTags},
+	"AWS::ElasticLoadBalancing::LoadBalancer":    {"Tags", ListTags},
+	"AWS::ElasticLoadBalancingV2::LoadBalancer":  {"Tags", ListTags},
+	"AWS::ElasticLoadBalancingV2::TargetGroup":   {"Tags", ListTags},
+	"AWS::Elasticsearch::Domain":                 {"Tags", ListTags},
+	"AWS::Events::EventBus":                      {"Tags", ListTags},
+	"AWS::Events::Rule":                          {"Tags", ListTags},
+	"AWS::Glue::Crawler":                         {"Tags", MapTags},
+	"AWS::Glue::DevEndpoint":                     {"Tags", MapTags},
+	"AWS::Glue::Job":                             {"Tags", MapTags},
+	"AWS::Glue::Trigger":                         {"Tags", MapTags},
+	"AWS::Glue::Workflow":                        {"Tags", MapTags},
+	"AWS::IAM::Role":                             {"Tags", ListTags},
+	"AWS::IAM::User":                             {"Tags", ListTags},
+	"AWS::KMS::Key":                              {"Tags", ListTags},
+	"AWS::Kinesis::Stream":                       {"Tags", ListTags},
+	"AWS::KinesisFirehose::DeliveryStream":       {"Tags", ListTags},
+	"AWS::Lambda::Function":                      {"Tags", ListTags},
+	"AWS::Logs::LogGroup":                        {"Tags", ListTags},
+	"AWS::MSK::Cluster":                          {"Tags", MapTags},
+	"AWS::Neptune::DBCluster":                    {"Tags", ListTags},
+	"AWS::Neptune::DBInstance":                   {"Tags", ListTags},
+	"AWS::Pinpoint::App":                         {"Tags", MapTags},
+	"AWS::RDS::DBCluster":                        {"Tags", ListTags},
+	"AWS::RDS::DBClusterParameterGroup":          {"Tags", ListTags},
+	"AWS::RDS::DBInstance":                       {"Tags", ListTags},
+	"AWS::RDS::DBParameterGroup":                 {"Tags", ListTags},
+	"AWS::RDS::DBSubnetGroup":                    {"Tags", ListTags},
+	"AWS::RDS::OptionGroup":                      {"Tags", ListTags},
+	"AWS::Redshift::Cluster":                     {"Tags", ListTags},
+	"AWS::Route53::HealthCheck":                  {"HealthCheckTags", ListTags},
+	"AWS::Route53::HostedZone":                   {"HostedZoneTags", ListTags},
+	"AWS::S3::Bucket":                            {"Tags", ListTags},
+	"AWS::SNS::Topic":                            {"Tags", ListTags},
+	"AWS::SQS::Queue":                            {"Tags", ListTags},
+	"AWS::SSM::Document":                         {"Tags", ListTags},
+	"AWS::SSM::Parameter":                        {"Tags", MapTags},
+	"AWS::SageMaker::Endpoint":                   {"Tags", ListTags},
+	"AWS::SageMaker::EndpointConfig":             {"Tags", ListTags},
+	"AWS::SageMaker::Model":                      {"Tags", ListTags},
+	"AWS::SageMaker::NotebookInstance":           {"Tags", ListTags},
+	"AWS::SecretsManager::Secret":                {"Tags", ListTags},
+	"AWS::Serverless::Api":                       {"Tags", MapTags},
+	"AWS::Serverless::Application":               {"Tags", MapTags},
+	"AWS::Serverless::Function":                  {"Tags", MapTags},
+	"AWS::Serverless::HttpApi":                   {"Tags", MapTags},
+	"AWS::Serverless::SimpleTable":               {"Tags", MapTags},
+	"AWS::Serverless::StateMachine":              {"Tags", MapTags},
+	"AWS::ServiceCatalog::CloudFormationProduct": {"Tags", ListTags},
+	"AWS::ServiceCatalog::Portfolio":             {"Tags", ListTags},
+	"AWS::StepFunctions::Activity":               {"Tags", ListTags},
+	"AWS::StepFunctions::StateMachine":           {"Tags", ListTags},
+	"AWS::Transfer::Server":                      {"Tags", ListTags},
+	"AWS::Transfer::User":                        {"Tags", ListTags},
+	"AWS::WorkSpaces::Workspace":                 {"Tags", ListTags},
+}
@@ -0,0 +1,43 @@
+// Package spec contains information about CloudFormation resource types
+// taken from the CloudFormation resource specification
+// and the AWS Serverless Application Model specification
+package spec
+
+// TagFormat describes the form taken by a resource type's tags property
+type TagFormat int
+
+const (
+	// NotTaggable means the resource type has no tags property
+	NotTaggable TagFormat = iota
+
+	// ListTags is a list of maps with Key and Value properties
+	ListTags
+
+	// MapTags is a map of tag names to values
+	MapTags
+
+	// PropagatingListTags is the same as ListTags
+	// but each tag also requires a PropagateAtLaunch property
+	PropagatingListTags
+)
+
+// tagProperty is the name and format of the property that holds a resource type's tags
+type tagProperty struct {
+	name   string
+	format TagFormat
+}
+
+// Tags returns the name of the property that holds the named resource type's tags
+// and the TagFormat of that property
+func Tags(resourceType string) (string, TagFormat) {
+	p := tagProperties[resourceType]
+
+	return p.name, p.format
+}
+
+// IsTaggable returns true if the named resource type has a tags property
+func IsTaggable(resourceType string) bool {
+	_, format := Tags(resourceType)
+
+	return format != NotTaggable
+}
@@ -0,0 +1,178 @@
+// Package tags checks and adds tags on the taggable resources in a cfn.Template
+package tags
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/spec"
+)
+
+// Missing returns the names of taggable resources that do not have
+// all of the required tags, mapped to the tags that they are missing.
+//
+// Resources whose tags are set by an intrinsic function, in whole or in part,
+// can't be checked and are reported as missing every required tag.
+func Missing(t cfn.Template, required []string) map[string][]string {
+	out := make(map[string][]string)
+
+	for name, resource := range taggable(t) {
+		property, _ := spec.Tags(resource["Type"].(string))
+
+		existing, ok := existingTags(resource, property)
+		if !ok {
+			existing = make(map[string]bool)
+		}
+
+		missing := make([]string, 0)
+		for _, key := range required {
+			if !existing[key] {
+				missing = append(missing, key)
+			}
+		}
+
+		if len(missing) > 0 {
+			out[name] = missing
+		}
+	}
+
+	return out
+}
+
+// Add adds tags to every taggable resource in the template that doesn't already have them.
+// Existing tag values are left unchanged.
+//
+// Add returns the names of any resources that could not be tagged
+// because their tags are set by an intrinsic function.
+func Add(t cfn.Template, tags map[string]string) []string {
+	failed := make([]string, 0)
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for name, resource := range taggable(t) {
+		property, format := spec.Tags(resource["Type"].(string))
+
+		existing, ok := existingTags(resource, property)
+		if !ok || !hasFormat(resource, property, format) {
+			failed = append(failed, name)
+			continue
+		}
+
+		props, ok := resource["Properties"].(map[string]interface{})
+		if !ok {
+			props = make(map[string]interface{})
+			resource["Properties"] = props
+		}
+
+		for _, key := range keys {
+			if existing[key] {
+				continue
+			}
+
+			switch format {
+			case spec.MapTags:
+				m, ok := props[property].(map[string]interface{})
+				if !ok {
+					m = make(map[string]interface{})
+				}
+				m[key] = tags[key]
+				props[property] = m
+			case spec.ListTags, spec.PropagatingListTags:
+				tag := map[string]interface{}{
+					"Key":   key,
+					"Value": tags[key],
+				}
+				if format == spec.PropagatingListTags {
+					tag["PropagateAtLaunch"] = true
+				}
+
+				l, _ := props[property].([]interface{})
+				props[property] = append(l, tag)
+			}
+		}
+	}
+
+	sort.Strings(failed)
+
+	return failed
+}
+
+// hasFormat returns true if the resource's tags are either unset
+// or are in the expected format
+func hasFormat(resource map[string]interface{}, property string, format spec.TagFormat) bool {
+	props, _ := resource["Properties"].(map[string]interface{})
+
+	switch props[property].(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return format == spec.MapTags
+	case []interface{}:
+		return format == spec.ListTags || format == spec.PropagatingListTags
+	}
+
+	return false
+}
+
+// taggable returns the taggable resources in a template
+func taggable(t cfn.Template) map[string]map[string]interface{} {
+	out := make(map[string]map[string]interface{})
+
+	resources, _ := t["Resources"].(map[string]interface{})
+	for name, r := range resources {
+		resource, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if typeName, ok := resource["Type"].(string); ok && spec.IsTaggable(typeName) {
+			out[name] = resource
+		}
+	}
+
+	return out
+}
+
+// existingTags returns the keys of tags already set on a resource.
+// It returns false if the tags can't be determined
+func existingTags(resource map[string]interface{}, property string) (map[string]bool, bool) {
+	out := make(map[string]bool)
+
+	props, _ := resource["Properties"].(map[string]interface{})
+
+	switch v := props[property].(type) {
+	case nil:
+		return out, true
+	case []interface{}:
+		for _, tag := range v {
+			tag, ok := tag.(map[string]interface{})
+			if !ok {
+				return out, false
+			}
+
+			key, ok := tag["Key"].(string)
+			if !ok {
+				return out, false
+			}
+
+			out[key] = true
+		}
+	case map[string]interface{}:
+		for key := range v {
+			if key == "Ref" || strings.HasPrefix(key, "Fn::") {
+				return out, false
+			}
+
+			out[key] = true
+		}
+	default:
+		return out, false
+	}
+
+	return out, true
+}
@@ -0,0 +1,176 @@
+package tags_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/cfn/tags"
+)
+
+const input = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      Tags:
+        - Key: Owner
+          Value: me
+  Function:
+    Type: AWS::Serverless::Function
+    Properties:
+      Handler: index.handler
+  Group:
+    Type: AWS::AutoScaling::AutoScalingGroup
+  Conditional:
+    Type: AWS::SQS::Queue
+    Properties:
+      Tags: !If
+        - HasTags
+        - []
+        - !Ref AWS::NoValue
+  Policy:
+    Type: AWS::S3::BucketPolicy
+`
+
+func TestMissing(t *testing.T) {
+	template, err := parse.String(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := tags.Missing(template, []string{"Owner", "CostCenter"})
+	expected := map[string][]string{
+		"Bucket":      {"CostCenter"},
+		"Function":    {"Owner", "CostCenter"},
+		"Group":       {"Owner", "CostCenter"},
+		"Conditional": {"Owner", "CostCenter"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v, want %v", actual, expected)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	template, err := parse.String(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := tags.Add(template, map[string]string{
+		"Owner":      "you",
+		"CostCenter": "123",
+	})
+
+	if !reflect.DeepEqual(failed, []string{"Conditional"}) {
+		t.Errorf("Unexpected failures: %v", failed)
+	}
+
+	expected := `Resources:
+  Bucket:
+    Type: "AWS::S3::Bucket"
+    Properties:
+      Tags:
+        - Key: Owner
+          Value: me
+        - Key: CostCenter
+          Value: "123"
+
+  Function:
+    Type: "AWS::Serverless::Function"
+    Properties:
+      Handler: index.handler
+      Tags:
+        CostCenter: "123"
+        Owner: you
+
+  Group:
+    Type: "AWS::AutoScaling::AutoScalingGroup"
+    Properties:
+      Tags:
+        - Key: CostCenter
+          PropagateAtLaunch: true
+          Value: "123"
+        - Key: Owner
+          PropagateAtLaunch: true
+          Value: you
+
+  Policy:
+    Type: "AWS::S3::BucketPolicy"
+
+  Conditional:
+    Type: "AWS::SQS::Queue"
+    Properties:
+      Tags: !If
+        - HasTags
+        - []
+        - Ref: "AWS::NoValue"`
+
+	actual := format.Template(template, format.Options{})
+
+	if actual != expected {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", actual, expected)
+	}
+
+	if len(tags.Missing(template, []string{"Owner", "CostCenter"})) != 1 {
+		t.Error("Tags are still missing after Add")
+	}
+}
+
+func TestTagProperties(t *testing.T) {
+	template, err := parse.String(`
+Resources:
+  FileSystem:
+    Type: AWS::EFS::FileSystem
+    Properties:
+      FileSystemTags:
+        - Key: Owner
+          Value: me
+  UserPool:
+    Type: AWS::Cognito::UserPool
+  Partial:
+    Type: AWS::SNS::Topic
+    Properties:
+      Tags:
+        - Key: Owner
+          Value: me
+        - !If
+          - HasTags
+          - Key: CostCenter
+            Value: "123"
+          - !Ref AWS::NoValue
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing := tags.Missing(template, []string{"Owner", "CostCenter"})
+	expected := map[string][]string{
+		"FileSystem": {"CostCenter"},
+		"UserPool":   {"Owner", "CostCenter"},
+		"Partial":    {"Owner", "CostCenter"},
+	}
+
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Got %v, want %v", missing, expected)
+	}
+
+	failed := tags.Add(template, map[string]string{"CostCenter": "123"})
+	if !reflect.DeepEqual(failed, []string{"Partial"}) {
+		t.Errorf("Unexpected failures: %v", failed)
+	}
+
+	resources := template["Resources"].(map[string]interface{})
+
+	fileSystemTags := resources["FileSystem"].(map[string]interface{})["Properties"].(map[string]interface{})["FileSystemTags"].([]interface{})
+	if len(fileSystemTags) != 2 {
+		t.Errorf("Expected a tag to be added to FileSystemTags, got %v", fileSystemTags)
+	}
+
+	userPoolTags := resources["UserPool"].(map[string]interface{})["Properties"].(map[string]interface{})["UserPoolTags"]
+	if !reflect.DeepEqual(userPoolTags, map[string]interface{}{"CostCenter": "123"}) {
+		t.Errorf("Expected a tag to be added to UserPoolTags, got %v", userPoolTags)
+	}
+}
@@ -1,15 +1,60 @@
 package cfn
 
 import (
-	"context"
+	"crypto/sha256"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/s3"
+	"github.com/aws-cloudformation/rain/config"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 )
 
+// maxTemplateBodySize is the largest template that can be passed directly as a TemplateBody
+const maxTemplateBodySize = 51200
+
+// maxTemplateSize is the largest template that CloudFormation will accept from S3
+const maxTemplateSize = 1024 * 1024
+
+// StackOptions are settings that CloudFormation uses when it creates or updates a stack
+type StackOptions struct {
+	// RoleARN is the service role that CloudFormation uses to make changes to the stack
+	RoleARN string
+
+	// NotificationARNs are SNS topics that receive the stack's events
+	NotificationARNs []string
+
+	// RollbackAlarms are the ARNs of CloudWatch alarms that roll the stack back
+	RollbackAlarms []string
+
+	// MonitoringTime is how many minutes CloudFormation watches the alarms for after deploying
+	MonitoringTime int64
+}
+
+// rollbackConfiguration returns nil unless rollback alarms or a monitoring time have been set,
+// in which case the stack's existing configuration is replaced
+func (o StackOptions) rollbackConfiguration() *cloudformation.RollbackConfiguration {
+	if len(o.RollbackAlarms) == 0 && o.MonitoringTime == 0 {
+		return nil
+	}
+
+	triggers := make([]cloudformation.RollbackTrigger, len(o.RollbackAlarms))
+	for i, alarm := range o.RollbackAlarms {
+		triggers[i] = cloudformation.RollbackTrigger{
+			Arn:  aws.String(alarm),
+			Type: aws.String("AWS::CloudWatch::Alarm"),
+		}
+	}
+
+	return &cloudformation.RollbackConfiguration{
+		MonitoringTimeInMinutes: aws.Int64(o.MonitoringTime),
+		RollbackTriggers:        triggers,
+	}
+}
+
 var liveStatuses = []cloudformation.StackStatus{
 	"CREATE_IN_PROGRESS",
 	"CREATE_FAILED",
@@ -27,24 +72,42 @@ var liveStatuses = []cloudformation.StackStatus{
 	"UPDATE_ROLLBACK_COMPLETE_CLEANUP_IN_PROGRESS",
 	"UPDATE_ROLLBACK_COMPLETE",
 	"REVIEW_IN_PROGRESS",
+	"IMPORT_IN_PROGRESS",
+	"IMPORT_COMPLETE",
+	"IMPORT_ROLLBACK_IN_PROGRESS",
+	"IMPORT_ROLLBACK_FAILED",
+	"IMPORT_ROLLBACK_COMPLETE",
 }
 
-func getClient() *cloudformation.Client {
-	return cloudformation.New(client.Config())
+// Client makes calls to CloudFormation in a single region
+type Client struct {
+	region string
 }
 
-func GetStackTemplate(stackName string, processed bool) (string, client.Error) {
+// Default makes calls in the configured region
+var Default = Client{}
+
+// In returns a Client that makes calls in region
+func In(region string) Client {
+	return Client{region}
+}
+
+func (c Client) api() *cloudformation.Client {
+	return cloudformation.New(client.ConfigFor(c.region))
+}
+
+func (c Client) GetStackTemplate(stackName string, processed bool) (string, client.Error) {
 	templateStage := "Original"
 	if processed {
 		templateStage = "Processed"
 	}
 
-	req := getClient().GetTemplateRequest(&cloudformation.GetTemplateInput{
+	req := c.api().GetTemplateRequest(&cloudformation.GetTemplateInput{
 		StackName:     &stackName,
 		TemplateStage: cloudformation.TemplateStage(templateStage),
 	})
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
 	if err != nil {
 		return "", client.NewError(err)
 	}
@@ -52,8 +115,8 @@ func GetStackTemplate(stackName string, processed bool) (string, client.Error) {
 	return *res.TemplateBody, nil
 }
 
-func StackExists(stackName string) (bool, client.Error) {
-	stacks, err := ListStacks()
+func (c Client) StackExists(stackName string) (bool, client.Error) {
+	stacks, err := c.ListStacks()
 	if err != nil {
 		return false, err
 	}
@@ -67,39 +130,114 @@ func StackExists(stackName string) (bool, client.Error) {
 	return false, nil
 }
 
-func ListStacks() ([]cloudformation.StackSummary, client.Error) {
-	req := getClient().ListStacksRequest(&cloudformation.ListStacksInput{
+func (c Client) ListStacks() ([]cloudformation.StackSummary, client.Error) {
+	req := c.api().ListStacksRequest(&cloudformation.ListStacksInput{
 		StackStatusFilter: liveStatuses,
 	})
 
 	stacks := make([]cloudformation.StackSummary, 0)
 
 	p := cloudformation.NewListStacksPaginator(req)
-	for p.Next(context.Background()) {
+	for p.Next(client.Context()) {
 		stacks = append(stacks, p.CurrentPage().StackSummaries...)
 	}
 
 	return stacks, client.NewError(p.Err())
 }
 
-func DeleteStack(stackName string) client.Error {
-	// Get the stack properties
-	req := getClient().DeleteStackRequest(&cloudformation.DeleteStackInput{
+func (c Client) DeleteStack(stackName, roleArn string) client.Error {
+	input := &cloudformation.DeleteStackInput{
+		StackName: &stackName,
+	}
+
+	if roleArn != "" {
+		input.RoleARN = &roleArn
+	}
+
+	req := c.api().DeleteStackRequest(input)
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+// CancelUpdateStack stops an update that is in progress and rolls the stack back
+func (c Client) CancelUpdateStack(stackName string) client.Error {
+	req := c.api().CancelUpdateStackRequest(&cloudformation.CancelUpdateStackInput{
+		StackName: &stackName,
+	})
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+// ContinueUpdateRollback resumes rolling back a stack that is UPDATE_ROLLBACK_FAILED,
+// leaving the resources in skip in their current state
+func (c Client) ContinueUpdateRollback(stackName, roleArn string, skip []string) client.Error {
+	input := &cloudformation.ContinueUpdateRollbackInput{
+		StackName:       &stackName,
+		ResourcesToSkip: skip,
+	}
+
+	if roleArn != "" {
+		input.RoleARN = &roleArn
+	}
+
+	req := c.api().ContinueUpdateRollbackRequest(input)
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+// SetTerminationProtection enables or disables termination protection for the stack
+func (c Client) SetTerminationProtection(stackName string, enabled bool) client.Error {
+	req := c.api().UpdateTerminationProtectionRequest(&cloudformation.UpdateTerminationProtectionInput{
+		StackName:                   &stackName,
+		EnableTerminationProtection: &enabled,
+	})
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+func (c Client) GetStackPolicy(stackName string) (string, client.Error) {
+	req := c.api().GetStackPolicyRequest(&cloudformation.GetStackPolicyInput{
 		StackName: &stackName,
 	})
 
-	_, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return "", client.NewError(err)
+	}
+
+	if res.StackPolicyBody == nil {
+		return "", nil
+	}
+
+	return *res.StackPolicyBody, nil
+}
+
+func (c Client) SetStackPolicy(stackName, policy string) client.Error {
+	req := c.api().SetStackPolicyRequest(&cloudformation.SetStackPolicyInput{
+		StackName:       &stackName,
+		StackPolicyBody: &policy,
+	})
+
+	_, err := req.Send(client.Context())
 
 	return client.NewError(err)
 }
 
-func GetStack(stackName string) (cloudformation.Stack, client.Error) {
+func (c Client) GetStack(stackName string) (cloudformation.Stack, client.Error) {
 	// Get the stack properties
-	req := getClient().DescribeStacksRequest(&cloudformation.DescribeStacksInput{
+	req := c.api().DescribeStacksRequest(&cloudformation.DescribeStacksInput{
 		StackName: &stackName,
 	})
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
 	if err != nil {
 		return cloudformation.Stack{}, client.NewError(err)
 	}
@@ -107,13 +245,13 @@ func GetStack(stackName string) (cloudformation.Stack, client.Error) {
 	return res.Stacks[0], nil
 }
 
-func GetStackResources(stackName string) ([]cloudformation.StackResource, client.Error) {
+func (c Client) GetStackResources(stackName string) ([]cloudformation.StackResource, client.Error) {
 	// Get the stack resources
-	req := getClient().DescribeStackResourcesRequest(&cloudformation.DescribeStackResourcesInput{
+	req := c.api().DescribeStackResourcesRequest(&cloudformation.DescribeStackResourcesInput{
 		StackName: &stackName,
 	})
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
 	if err != nil {
 		return nil, client.NewError(err)
 	}
@@ -121,16 +259,30 @@ func GetStackResources(stackName string) ([]cloudformation.StackResource, client
 	return res.StackResources, nil
 }
 
-func GetStackEvents(stackName string) ([]cloudformation.StackEvent, client.Error) {
-	req := getClient().DescribeStackEventsRequest(&cloudformation.DescribeStackEventsInput{
+func (c Client) GetStackEvents(stackName string) ([]cloudformation.StackEvent, client.Error) {
+	return c.GetStackEventsUntil(stackName, func(cloudformation.StackEvent) bool {
+		return false
+	})
+}
+
+// GetStackEventsUntil returns the stack's events, newest first,
+// without fetching any more pages once stop has returned true for one of them
+func (c Client) GetStackEventsUntil(stackName string, stop func(cloudformation.StackEvent) bool) ([]cloudformation.StackEvent, client.Error) {
+	req := c.api().DescribeStackEventsRequest(&cloudformation.DescribeStackEventsInput{
 		StackName: &stackName,
 	})
 
 	events := make([]cloudformation.StackEvent, 0)
 
 	p := cloudformation.NewDescribeStackEventsPaginator(req)
-	for p.Next(context.Background()) {
+	for p.Next(client.Context()) {
 		events = append(events, p.CurrentPage().StackEvents...)
+
+		for _, event := range p.CurrentPage().StackEvents {
+			if stop(event) {
+				return events, nil
+			}
+		}
 	}
 
 	return events, client.NewError(p.Err())
@@ -149,10 +301,68 @@ func makeTags(tags map[string]string) []cloudformation.Tag {
 	return out
 }
 
-func CreateChangeSet(template string, params []cloudformation.Parameter, tags map[string]string, stackName string) (string, client.Error) {
+func makeCapabilities(capabilities []string) []cloudformation.Capability {
+	out := make([]cloudformation.Capability, len(capabilities))
+
+	for i, capability := range capabilities {
+		out[i] = cloudformation.Capability(capability)
+	}
+
+	return out
+}
+
+// templateKey returns the key that template is stored under in the artifact bucket
+func templateKey(template string) string {
+	return fmt.Sprintf("%x.template", sha256.Sum256([]byte(template)))
+}
+
+// uploadTemplate stores template in bucket under key and returns its URL
+func (c Client) uploadTemplate(template, bucket, key string) (string, client.Error) {
+	if !s3.In(c.region).ObjectExists(bucket, key) {
+		config.Debugf("Uploading template: s3://%s/%s", bucket, key)
+
+		err := s3.In(c.region).PutObject(bucket, key, []byte(template))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return s3.In(c.region).ObjectURL(bucket, key), nil
+}
+
+// templateSource returns either a TemplateBody or a TemplateURL for template,
+// uploading the template to bucket if it is too large to send directly
+func (c Client) templateSource(template, bucket string) (*string, *string, client.Error) {
+	return chooseTemplateSource(template, func(key string) (string, client.Error) {
+		return c.uploadTemplate(template, bucket, key)
+	})
+}
+
+// chooseTemplateSource returns either a TemplateBody or a TemplateURL for template.
+// upload is called with the template's key to store templates that are too large to send directly
+func chooseTemplateSource(template string, upload func(key string) (string, client.Error)) (*string, *string, client.Error) {
+	if len(template) > maxTemplateSize {
+		return nil, nil, client.NewError(fmt.Errorf("Template is %d bytes, which is larger than CloudFormation's limit of %d bytes", len(template), maxTemplateSize))
+	}
+
+	if len(template) <= maxTemplateBodySize {
+		return &template, nil, nil
+	}
+
+	templateURL, err := upload(templateKey(template))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nil, &templateURL, nil
+}
+
+// CreateChangeSet creates a change set for stackName and waits for it to be ready.
+// Templates that are too large to send directly are uploaded to bucket first.
+func (c Client) CreateChangeSet(template string, params []cloudformation.Parameter, tags map[string]string, stackName string, capabilities []string, bucket string, options StackOptions) (string, client.Error) {
 	changeSetType := "CREATE"
 
-	exists, err := StackExists(stackName)
+	exists, err := c.StackExists(stackName)
 	if err != nil {
 		return "", err
 	}
@@ -161,27 +371,47 @@ func CreateChangeSet(template string, params []cloudformation.Parameter, tags ma
 		changeSetType = "UPDATE"
 	}
 
+	return c.createChangeSet(changeSetType, template, params, tags, stackName, capabilities, bucket, options, nil)
+}
+
+// createChangeSet creates a change set and waits for it to be ready.
+// If build is not nil, it is run once the request has been built.
+func (c Client) createChangeSet(changeSetType string, template string, params []cloudformation.Parameter, tags map[string]string, stackName string, capabilities []string, bucket string, options StackOptions, build func(*aws.Request)) (string, client.Error) {
 	changeSetName := stackName + "-" + fmt.Sprint(time.Now().Unix())
 
-	req := getClient().CreateChangeSetRequest(&cloudformation.CreateChangeSetInput{
+	input := &cloudformation.CreateChangeSetInput{
 		ChangeSetType: cloudformation.ChangeSetType(changeSetType),
 		ChangeSetName: &changeSetName,
 		StackName:     &stackName,
-		TemplateBody:  &template,
 		Tags:          makeTags(tags),
 		Parameters:    params,
-		Capabilities: []cloudformation.Capability{
-			"CAPABILITY_NAMED_IAM",
-			"CAPABILITY_AUTO_EXPAND",
-		},
-	})
+		Capabilities:  makeCapabilities(capabilities),
+
+		NotificationARNs:      options.NotificationARNs,
+		RollbackConfiguration: options.rollbackConfiguration(),
+	}
 
-	_, err = req.Send(context.Background())
+	if options.RoleARN != "" {
+		input.RoleARN = &options.RoleARN
+	}
+
+	var err client.Error
+	input.TemplateBody, input.TemplateURL, err = c.templateSource(template, bucket)
 	if err != nil {
 		return changeSetName, err
 	}
 
-	err = getClient().WaitUntilChangeSetCreateComplete(context.Background(), &cloudformation.DescribeChangeSetInput{
+	req := c.api().CreateChangeSetRequest(input)
+	if build != nil {
+		req.Handlers.Build.PushBack(build)
+	}
+
+	_, err = req.Send(client.Context())
+	if err != nil {
+		return changeSetName, err
+	}
+
+	err = c.api().WaitUntilChangeSetCreateComplete(client.Context(), &cloudformation.DescribeChangeSetInput{
 		ChangeSetName: &changeSetName,
 		StackName:     &stackName,
 	})
@@ -189,51 +419,184 @@ func CreateChangeSet(template string, params []cloudformation.Parameter, tags ma
 	return changeSetName, client.NewError(err)
 }
 
-func GetChangeSet(stackName, changeSetName string) ([]cloudformation.Change, client.Error) {
-	req := getClient().DescribeChangeSetRequest(&cloudformation.DescribeChangeSetInput{
+func (c Client) GetChangeSet(stackName, changeSetName string) ([]cloudformation.Change, client.Error) {
+	changes := make([]cloudformation.Change, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().DescribeChangeSetRequest(&cloudformation.DescribeChangeSetInput{
+			ChangeSetName: &changeSetName,
+			StackName:     &stackName,
+			NextToken:     nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return changes, client.NewError(err)
+		}
+
+		changes = append(changes, res.Changes...)
+
+		if res.NextToken == nil || *res.NextToken == "" {
+			return changes, nil
+		}
+
+		nextToken = res.NextToken
+	}
+}
+
+func (c Client) ListChangeSets(stackName string) ([]cloudformation.ChangeSetSummary, client.Error) {
+	changeSets := make([]cloudformation.ChangeSetSummary, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().ListChangeSetsRequest(&cloudformation.ListChangeSetsInput{
+			StackName: &stackName,
+			NextToken: nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return changeSets, client.NewError(err)
+		}
+
+		changeSets = append(changeSets, res.Summaries...)
+
+		if res.NextToken == nil || *res.NextToken == "" {
+			return changeSets, nil
+		}
+
+		nextToken = res.NextToken
+	}
+}
+
+func (c Client) DescribeChangeSet(stackName, changeSetName string) (cloudformation.DescribeChangeSetOutput, client.Error) {
+	req := c.api().DescribeChangeSetRequest(&cloudformation.DescribeChangeSetInput{
 		ChangeSetName: &changeSetName,
 		StackName:     &stackName,
 	})
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return cloudformation.DescribeChangeSetOutput{}, client.NewError(err)
+	}
+
+	return *res.DescribeChangeSetOutput, nil
+}
+
+func (c Client) GetChangeSetTemplate(stackName, changeSetName string) (string, client.Error) {
+	req := c.api().GetTemplateRequest(&cloudformation.GetTemplateInput{
+		StackName:     &stackName,
+		ChangeSetName: &changeSetName,
+		TemplateStage: cloudformation.TemplateStageOriginal,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return "", client.NewError(err)
+	}
 
-	return res.Changes, client.NewError(err)
+	return *res.TemplateBody, nil
 }
 
-func ExecuteChangeSet(stackName, changeSetName string) client.Error {
-	req := getClient().ExecuteChangeSetRequest(&cloudformation.ExecuteChangeSetInput{
+func (c Client) ExecuteChangeSet(stackName, changeSetName string, disableRollback bool) client.Error {
+	req := c.api().ExecuteChangeSetRequest(&cloudformation.ExecuteChangeSetInput{
 		ChangeSetName: &changeSetName,
 		StackName:     &stackName,
 	})
 
-	_, err := req.Send(context.Background())
+	// This version of the SDK predates the DisableRollback parameter,
+	// so it is added to the request once the request has been built
+	if disableRollback {
+		req.Handlers.Build.PushBack(appendQuery("DisableRollback=true"))
+	}
+
+	_, err := req.Send(client.Context())
 
 	return client.NewError(err)
 }
 
-func DeleteChangeSet(stackName, changeSetName string) client.Error {
-	req := getClient().DeleteChangeSetRequest(&cloudformation.DeleteChangeSetInput{
+// appendQuery returns a Build handler that adds the encoded query parameters to the request's body
+func appendQuery(query string) func(*aws.Request) {
+	return func(r *aws.Request) {
+		body, err := ioutil.ReadAll(r.GetBody())
+		if err != nil {
+			r.Error = err
+			return
+		}
+
+		r.SetBufferBody(append(body, []byte("&"+query)...))
+	}
+}
+
+func (c Client) DeleteChangeSet(stackName, changeSetName string) client.Error {
+	req := c.api().DeleteChangeSetRequest(&cloudformation.DeleteChangeSetInput{
 		ChangeSetName: &changeSetName,
 		StackName:     &stackName,
 	})
 
-	_, err := req.Send(context.Background())
+	_, err := req.Send(client.Context())
 
 	return client.NewError(err)
 }
 
-func WaitUntilStackExists(stackName string) client.Error {
-	err := getClient().WaitUntilStackExists(context.Background(), &cloudformation.DescribeStacksInput{
+func (c Client) WaitUntilStackExists(stackName string) client.Error {
+	err := c.api().WaitUntilStackExists(client.Context(), &cloudformation.DescribeStacksInput{
 		StackName: &stackName,
 	})
 
 	return client.NewError(err)
 }
 
-func WaitUntilStackCreateComplete(stackName string) client.Error {
-	err := getClient().WaitUntilStackCreateComplete(context.Background(), &cloudformation.DescribeStacksInput{
+func (c Client) WaitUntilStackCreateComplete(stackName string) client.Error {
+	err := c.api().WaitUntilStackCreateComplete(client.Context(), &cloudformation.DescribeStacksInput{
 		StackName: &stackName,
 	})
 
 	return client.NewError(err)
 }
+
+// DetectStackDrift starts drift detection for the stack and returns the ID of the detection
+func (c Client) DetectStackDrift(stackName string) (string, client.Error) {
+	req := c.api().DetectStackDriftRequest(&cloudformation.DetectStackDriftInput{
+		StackName: &stackName,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return "", client.NewError(err)
+	}
+
+	return *res.StackDriftDetectionId, nil
+}
+
+func (c Client) GetStackDriftDetectionStatus(detectionId string) (cloudformation.DescribeStackDriftDetectionStatusOutput, client.Error) {
+	req := c.api().DescribeStackDriftDetectionStatusRequest(&cloudformation.DescribeStackDriftDetectionStatusInput{
+		StackDriftDetectionId: &detectionId,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return cloudformation.DescribeStackDriftDetectionStatusOutput{}, client.NewError(err)
+	}
+
+	return *res.DescribeStackDriftDetectionStatusOutput, nil
+}
+
+// GetStackResourceDrifts returns the results of the stack's most recent drift detection
+func (c Client) GetStackResourceDrifts(stackName string) ([]cloudformation.StackResourceDrift, client.Error) {
+	req := c.api().DescribeStackResourceDriftsRequest(&cloudformation.DescribeStackResourceDriftsInput{
+		StackName: &stackName,
+	})
+
+	drifts := make([]cloudformation.StackResourceDrift, 0)
+
+	p := cloudformation.NewDescribeStackResourceDriftsPaginator(req)
+	for p.Next(client.Context()) {
+		drifts = append(drifts, p.CurrentPage().StackResourceDrifts...)
+	}
+
+	return drifts, client.NewError(p.Err())
+}
@@ -0,0 +1,132 @@
+package cfn
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// testAPI returns a CloudFormation client that can build requests without any AWS configuration
+func testAPI() *cloudformation.Client {
+	cfg := defaults.Config()
+	cfg.Region = "us-east-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+
+	return cloudformation.New(cfg)
+}
+
+// buildBody builds req and returns its decoded body
+func buildBody(t *testing.T, req *aws.Request) url.Values {
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadAll(req.GetBody())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return values
+}
+
+func TestDisableRollback(t *testing.T) {
+	req := testAPI().ExecuteChangeSetRequest(&cloudformation.ExecuteChangeSetInput{
+		ChangeSetName: aws.String("change-set"),
+		StackName:     aws.String("stack"),
+	})
+	req.Handlers.Build.PushBack(appendQuery("DisableRollback=true"))
+
+	values := buildBody(t, req.Request)
+
+	expected := map[string]string{
+		"Action":          "ExecuteChangeSet",
+		"ChangeSetName":   "change-set",
+		"StackName":       "stack",
+		"DisableRollback": "true",
+	}
+
+	for key, value := range expected {
+		if values.Get(key) != value {
+			t.Errorf("Expected %s=%s, got %q", key, value, values.Get(key))
+		}
+	}
+}
+
+func TestTemplateSource(t *testing.T) {
+	cases := []struct {
+		name     string
+		size     int
+		uploaded bool
+	}{
+		{"small", 100, false},
+		{"at the limit", maxTemplateBodySize, false},
+		{"over the limit", maxTemplateBodySize + 1, true},
+		{"largest", maxTemplateSize, true},
+	}
+
+	for _, c := range cases {
+		template := strings.Repeat("x", c.size)
+
+		var uploadedKey string
+		body, templateURL, err := chooseTemplateSource(template, func(key string) (string, client.Error) {
+			uploadedKey = key
+			return "https://bucket.s3.us-east-1.amazonaws.com/" + key, nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+
+		req := testAPI().CreateChangeSetRequest(&cloudformation.CreateChangeSetInput{
+			ChangeSetName: aws.String("change-set"),
+			StackName:     aws.String("stack"),
+			TemplateBody:  body,
+			TemplateURL:   templateURL,
+		})
+
+		values := buildBody(t, req.Request)
+
+		if !c.uploaded {
+			if uploadedKey != "" || values.Get("TemplateURL") != "" || values.Get("TemplateBody") != template {
+				t.Errorf("%s: expected the template to be sent as TemplateBody", c.name)
+			}
+			continue
+		}
+
+		expectedKey := fmt.Sprintf("%x.template", sha256.Sum256([]byte(template)))
+		if uploadedKey != expectedKey {
+			t.Errorf("%s: expected the template to be uploaded as %s, got %q", c.name, expectedKey, uploadedKey)
+		}
+
+		if values.Get("TemplateBody") != "" {
+			t.Errorf("%s: unexpected TemplateBody", c.name)
+		}
+
+		if values.Get("TemplateURL") != "https://bucket.s3.us-east-1.amazonaws.com/"+expectedKey {
+			t.Errorf("%s: unexpected TemplateURL %q", c.name, values.Get("TemplateURL"))
+		}
+	}
+}
+
+func TestTemplateSourceTooLarge(t *testing.T) {
+	_, _, err := chooseTemplateSource(strings.Repeat("x", maxTemplateSize+1), func(key string) (string, client.Error) {
+		t.Error("Templates that are too large should not be uploaded")
+		return "", nil
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "larger than CloudFormation's limit") {
+		t.Errorf("Expected an error about the template size, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package cfn
+
+import (
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// The functions below call the Default client, in the configured region
+
+func GetStackTemplate(stackName string, processed bool) (string, client.Error) {
+	return Default.GetStackTemplate(stackName, processed)
+}
+
+func StackExists(stackName string) (bool, client.Error) {
+	return Default.StackExists(stackName)
+}
+
+func ListStacks() ([]cloudformation.StackSummary, client.Error) {
+	return Default.ListStacks()
+}
+
+func DeleteStack(stackName, roleArn string) client.Error {
+	return Default.DeleteStack(stackName, roleArn)
+}
+
+func CancelUpdateStack(stackName string) client.Error {
+	return Default.CancelUpdateStack(stackName)
+}
+
+func ContinueUpdateRollback(stackName, roleArn string, skip []string) client.Error {
+	return Default.ContinueUpdateRollback(stackName, roleArn, skip)
+}
+
+func SetTerminationProtection(stackName string, enabled bool) client.Error {
+	return Default.SetTerminationProtection(stackName, enabled)
+}
+
+func GetStackPolicy(stackName string) (string, client.Error) {
+	return Default.GetStackPolicy(stackName)
+}
+
+func SetStackPolicy(stackName, policy string) client.Error {
+	return Default.SetStackPolicy(stackName, policy)
+}
+
+func GetStack(stackName string) (cloudformation.Stack, client.Error) {
+	return Default.GetStack(stackName)
+}
+
+func GetStackResources(stackName string) ([]cloudformation.StackResource, client.Error) {
+	return Default.GetStackResources(stackName)
+}
+
+func GetStackEvents(stackName string) ([]cloudformation.StackEvent, client.Error) {
+	return Default.GetStackEvents(stackName)
+}
+
+func CreateChangeSet(template string, params []cloudformation.Parameter, tags map[string]string, stackName string, capabilities []string, bucket string, options StackOptions) (string, client.Error) {
+	return Default.CreateChangeSet(template, params, tags, stackName, capabilities, bucket, options)
+}
+
+func GetChangeSet(stackName, changeSetName string) ([]cloudformation.Change, client.Error) {
+	return Default.GetChangeSet(stackName, changeSetName)
+}
+
+func ListChangeSets(stackName string) ([]cloudformation.ChangeSetSummary, client.Error) {
+	return Default.ListChangeSets(stackName)
+}
+
+func DescribeChangeSet(stackName, changeSetName string) (cloudformation.DescribeChangeSetOutput, client.Error) {
+	return Default.DescribeChangeSet(stackName, changeSetName)
+}
+
+func GetChangeSetTemplate(stackName, changeSetName string) (string, client.Error) {
+	return Default.GetChangeSetTemplate(stackName, changeSetName)
+}
+
+func ExecuteChangeSet(stackName, changeSetName string, disableRollback bool) client.Error {
+	return Default.ExecuteChangeSet(stackName, changeSetName, disableRollback)
+}
+
+func DeleteChangeSet(stackName, changeSetName string) client.Error {
+	return Default.DeleteChangeSet(stackName, changeSetName)
+}
+
+func WaitUntilStackExists(stackName string) client.Error {
+	return Default.WaitUntilStackExists(stackName)
+}
+
+func WaitUntilStackCreateComplete(stackName string) client.Error {
+	return Default.WaitUntilStackCreateComplete(stackName)
+}
+
+func DetectStackDrift(stackName string) (string, client.Error) {
+	return Default.DetectStackDrift(stackName)
+}
+
+func GetStackDriftDetectionStatus(detectionId string) (cloudformation.DescribeStackDriftDetectionStatusOutput, client.Error) {
+	return Default.GetStackDriftDetectionStatus(detectionId)
+}
+
+func GetStackResourceDrifts(stackName string) ([]cloudformation.StackResourceDrift, client.Error) {
+	return Default.GetStackResourceDrifts(stackName)
+}
+
+func GetStackSet(stackSetName string) (cloudformation.StackSet, client.Error) {
+	return Default.GetStackSet(stackSetName)
+}
+
+func ListStackSets() ([]cloudformation.StackSetSummary, client.Error) {
+	return Default.ListStackSets()
+}
+
+func ListStackInstances(stackSetName string) ([]cloudformation.StackInstanceSummary, client.Error) {
+	return Default.ListStackInstances(stackSetName)
+}
+
+func CreateStackSet(stackSetName, template string, params []cloudformation.Parameter, tags map[string]string, capabilities []string, adminRole, execRole, bucket string) client.Error {
+	return Default.CreateStackSet(stackSetName, template, params, tags, capabilities, adminRole, execRole, bucket)
+}
+
+func UpdateStackSet(stackSetName, template string, params []cloudformation.Parameter, tags map[string]string, capabilities []string, adminRole, execRole, bucket string, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	return Default.UpdateStackSet(stackSetName, template, params, tags, capabilities, adminRole, execRole, bucket, prefs)
+}
+
+func CreateStackInstances(stackSetName string, accounts, regions []string, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	return Default.CreateStackInstances(stackSetName, accounts, regions, prefs)
+}
+
+func DeleteStackInstances(stackSetName string, accounts, regions []string, retainStacks bool, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	return Default.DeleteStackInstances(stackSetName, accounts, regions, retainStacks, prefs)
+}
+
+func DeleteStackSet(stackSetName string) client.Error {
+	return Default.DeleteStackSet(stackSetName)
+}
+
+func GetStackSetOperation(stackSetName, operationId string) (cloudformation.StackSetOperation, client.Error) {
+	return Default.GetStackSetOperation(stackSetName, operationId)
+}
+
+func ListStackSetOperations(stackSetName string) ([]cloudformation.StackSetOperationSummary, client.Error) {
+	return Default.ListStackSetOperations(stackSetName)
+}
+
+func ListStackSetOperationResults(stackSetName, operationId string) ([]cloudformation.StackSetOperationResultSummary, client.Error) {
+	return Default.ListStackSetOperationResults(stackSetName, operationId)
+}
+
+func GetResourceIdentifiers(template, bucket string) (map[string]ResourceIdentifier, client.Error) {
+	return Default.GetResourceIdentifiers(template, bucket)
+}
+
+func CreateImportChangeSet(template string, params []cloudformation.Parameter, tags map[string]string, stackName string, capabilities []string, bucket string, options StackOptions, resources []ResourceToImport) (string, client.Error) {
+	return Default.CreateImportChangeSet(template, params, tags, stackName, capabilities, bucket, options, resources)
+}
@@ -0,0 +1,115 @@
+package cfn
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// This version of the SDK predates resource import, so the parameters
+// and results that import needs are added to and read from the raw requests
+
+const changeSetTypeImport = "IMPORT"
+
+// ResourceIdentifier describes the properties that identify
+// an existing resource when it is imported into a stack
+type ResourceIdentifier struct {
+	ResourceType string
+	Keys         []string
+}
+
+// ResourceToImport is an existing resource to be imported into a stack
+type ResourceToImport struct {
+	LogicalResourceId string
+	ResourceType      string
+	Identifier        map[string]string
+}
+
+// GetResourceIdentifiers returns the identifier properties of each resource in the template,
+// keyed by logical resource ID
+func (c Client) GetResourceIdentifiers(template, bucket string) (map[string]ResourceIdentifier, client.Error) {
+	input := &cloudformation.GetTemplateSummaryInput{}
+
+	var err client.Error
+	input.TemplateBody, input.TemplateURL, err = c.templateSource(template, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary struct {
+		Summaries []struct {
+			ResourceType        string
+			LogicalResourceIds  []string `xml:"LogicalResourceIds>member"`
+			ResourceIdentifiers []string `xml:"ResourceIdentifiers>member"`
+		} `xml:"GetTemplateSummaryResult>ResourceIdentifierSummaries>member"`
+	}
+
+	req := c.api().GetTemplateSummaryRequest(input)
+	req.Handlers.Unmarshal.PushFront(func(r *aws.Request) {
+		data, err := ioutil.ReadAll(r.HTTPResponse.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+		if err := xml.Unmarshal(data, &summary); err != nil {
+			r.Error = err
+		}
+	})
+
+	_, sendErr := req.Send(client.Context())
+	if sendErr != nil {
+		return nil, client.NewError(sendErr)
+	}
+
+	out := make(map[string]ResourceIdentifier)
+	for _, s := range summary.Summaries {
+		for _, id := range s.LogicalResourceIds {
+			out[id] = ResourceIdentifier{
+				ResourceType: s.ResourceType,
+				Keys:         s.ResourceIdentifiers,
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// encodeResourcesToImport encodes resources as CreateChangeSet's ResourcesToImport parameter
+func encodeResourcesToImport(resources []ResourceToImport) string {
+	values := url.Values{}
+
+	for i, resource := range resources {
+		prefix := fmt.Sprintf("ResourcesToImport.member.%d.", i+1)
+		values.Set(prefix+"LogicalResourceId", resource.LogicalResourceId)
+		values.Set(prefix+"ResourceType", resource.ResourceType)
+
+		keys := make([]string, 0, len(resource.Identifier))
+		for key := range resource.Identifier {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for j, key := range keys {
+			entry := fmt.Sprintf("%sResourceIdentifier.entry.%d.", prefix, j+1)
+			values.Set(entry+"key", key)
+			values.Set(entry+"value", resource.Identifier[key])
+		}
+	}
+
+	return values.Encode()
+}
+
+// CreateImportChangeSet creates a change set that imports existing resources into the stack.
+// The template must contain the stack's existing resources as well as the imported ones.
+func (c Client) CreateImportChangeSet(template string, params []cloudformation.Parameter, tags map[string]string, stackName string, capabilities []string, bucket string, options StackOptions, resources []ResourceToImport) (string, client.Error) {
+	return c.createChangeSet(changeSetTypeImport, template, params, tags, stackName, capabilities, bucket, options, appendQuery(encodeResourcesToImport(resources)))
+}
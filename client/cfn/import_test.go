@@ -0,0 +1,110 @@
+package cfn
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestEncodeResourcesToImport(t *testing.T) {
+	cases := []struct {
+		name      string
+		resources []ResourceToImport
+		expected  map[string]string
+	}{
+		{
+			name: "single key",
+			resources: []ResourceToImport{
+				{
+					LogicalResourceId: "Bucket",
+					ResourceType:      "AWS::S3::Bucket",
+					Identifier:        map[string]string{"BucketName": "my-bucket"},
+				},
+			},
+			expected: map[string]string{
+				"ResourcesToImport.member.1.LogicalResourceId":                "Bucket",
+				"ResourcesToImport.member.1.ResourceType":                     "AWS::S3::Bucket",
+				"ResourcesToImport.member.1.ResourceIdentifier.entry.1.key":   "BucketName",
+				"ResourcesToImport.member.1.ResourceIdentifier.entry.1.value": "my-bucket",
+			},
+		},
+		{
+			name: "multiple resources and keys",
+			resources: []ResourceToImport{
+				{
+					LogicalResourceId: "Table",
+					ResourceType:      "AWS::DynamoDB::Table",
+					Identifier:        map[string]string{"TableName": "my-table"},
+				},
+				{
+					LogicalResourceId: "Route",
+					ResourceType:      "AWS::EC2::Route",
+					Identifier: map[string]string{
+						"RouteTableId":         "rtb-1234",
+						"DestinationCidrBlock": "0.0.0.0/0",
+					},
+				},
+			},
+			expected: map[string]string{
+				"ResourcesToImport.member.1.LogicalResourceId":                "Table",
+				"ResourcesToImport.member.1.ResourceType":                     "AWS::DynamoDB::Table",
+				"ResourcesToImport.member.1.ResourceIdentifier.entry.1.key":   "TableName",
+				"ResourcesToImport.member.1.ResourceIdentifier.entry.1.value": "my-table",
+				"ResourcesToImport.member.2.LogicalResourceId":                "Route",
+				"ResourcesToImport.member.2.ResourceType":                     "AWS::EC2::Route",
+				"ResourcesToImport.member.2.ResourceIdentifier.entry.1.key":   "DestinationCidrBlock",
+				"ResourcesToImport.member.2.ResourceIdentifier.entry.1.value": "0.0.0.0/0",
+				"ResourcesToImport.member.2.ResourceIdentifier.entry.2.key":   "RouteTableId",
+				"ResourcesToImport.member.2.ResourceIdentifier.entry.2.value": "rtb-1234",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		values, err := url.ParseQuery(encodeResourcesToImport(c.resources))
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+
+		if len(values) != len(c.expected) {
+			t.Errorf("%s: expected %d parameters, got %d: %v", c.name, len(c.expected), len(values), values)
+		}
+
+		for key, value := range c.expected {
+			if values.Get(key) != value {
+				t.Errorf("%s: expected %s=%s, got %q", c.name, key, value, values.Get(key))
+			}
+		}
+	}
+}
+
+func TestImportChangeSetRequest(t *testing.T) {
+	req := testAPI().CreateChangeSetRequest(&cloudformation.CreateChangeSetInput{
+		ChangeSetName: aws.String("change-set"),
+		ChangeSetType: changeSetTypeImport,
+		StackName:     aws.String("stack"),
+	})
+	req.Handlers.Build.PushBack(appendQuery(encodeResourcesToImport([]ResourceToImport{
+		{
+			LogicalResourceId: "Bucket",
+			ResourceType:      "AWS::S3::Bucket",
+			Identifier:        map[string]string{"BucketName": "my-bucket"},
+		},
+	})))
+
+	values := buildBody(t, req.Request)
+
+	expected := map[string]string{
+		"Action":        "CreateChangeSet",
+		"ChangeSetType": "IMPORT",
+		"ResourcesToImport.member.1.ResourceIdentifier.entry.1.value": "my-bucket",
+	}
+
+	for key, value := range expected {
+		if values.Get(key) != value {
+			t.Errorf("Expected %s=%s, got %q", key, value, values.Get(key))
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package cfn
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// ErrStackSetNotFound is returned by GetStackSet if the stack set does not exist
+var ErrStackSetNotFound = errors.New("Stack set not found")
+
+func (c Client) GetStackSet(stackSetName string) (cloudformation.StackSet, client.Error) {
+	req := c.api().DescribeStackSetRequest(&cloudformation.DescribeStackSetInput{
+		StackSetName: &stackSetName,
+	})
+
+	res, err := req.Send(client.Context())
+	if err, ok := err.(awserr.Error); ok && err.Code() == cloudformation.ErrCodeStackSetNotFoundException {
+		return cloudformation.StackSet{}, ErrStackSetNotFound
+	} else if err != nil {
+		return cloudformation.StackSet{}, client.NewError(err)
+	}
+
+	return *res.StackSet, nil
+}
+
+func (c Client) ListStackSets() ([]cloudformation.StackSetSummary, client.Error) {
+	stackSets := make([]cloudformation.StackSetSummary, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().ListStackSetsRequest(&cloudformation.ListStackSetsInput{
+			Status:    cloudformation.StackSetStatusActive,
+			NextToken: nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return stackSets, client.NewError(err)
+		}
+
+		stackSets = append(stackSets, res.Summaries...)
+
+		if res.NextToken == nil {
+			return stackSets, nil
+		}
+
+		nextToken = res.NextToken
+	}
+}
+
+func (c Client) ListStackInstances(stackSetName string) ([]cloudformation.StackInstanceSummary, client.Error) {
+	instances := make([]cloudformation.StackInstanceSummary, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().ListStackInstancesRequest(&cloudformation.ListStackInstancesInput{
+			StackSetName: &stackSetName,
+			NextToken:    nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return instances, client.NewError(err)
+		}
+
+		instances = append(instances, res.Summaries...)
+
+		if res.NextToken == nil {
+			return instances, nil
+		}
+
+		nextToken = res.NextToken
+	}
+}
+
+func (c Client) CreateStackSet(stackSetName, template string, params []cloudformation.Parameter, tags map[string]string, capabilities []string, adminRole, execRole, bucket string) client.Error {
+	input := &cloudformation.CreateStackSetInput{
+		StackSetName: &stackSetName,
+		Parameters:   params,
+		Tags:         makeTags(tags),
+		Capabilities: makeCapabilities(capabilities),
+	}
+
+	if adminRole != "" {
+		input.AdministrationRoleARN = &adminRole
+	}
+
+	if execRole != "" {
+		input.ExecutionRoleName = &execRole
+	}
+
+	var err client.Error
+	input.TemplateBody, input.TemplateURL, err = c.templateSource(template, bucket)
+	if err != nil {
+		return err
+	}
+
+	req := c.api().CreateStackSetRequest(input)
+
+	_, sendErr := req.Send(client.Context())
+
+	return client.NewError(sendErr)
+}
+
+// UpdateStackSet updates the stack set and all of its instances
+// and returns the ID of the operation
+func (c Client) UpdateStackSet(stackSetName, template string, params []cloudformation.Parameter, tags map[string]string, capabilities []string, adminRole, execRole, bucket string, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	input := &cloudformation.UpdateStackSetInput{
+		StackSetName:         &stackSetName,
+		Parameters:           params,
+		Tags:                 makeTags(tags),
+		Capabilities:         makeCapabilities(capabilities),
+		OperationPreferences: &prefs,
+	}
+
+	if adminRole != "" {
+		input.AdministrationRoleARN = &adminRole
+	}
+
+	if execRole != "" {
+		input.ExecutionRoleName = &execRole
+	}
+
+	var err client.Error
+	input.TemplateBody, input.TemplateURL, err = c.templateSource(template, bucket)
+	if err != nil {
+		return "", err
+	}
+
+	req := c.api().UpdateStackSetRequest(input)
+
+	res, sendErr := req.Send(client.Context())
+	if sendErr != nil {
+		return "", client.NewError(sendErr)
+	}
+
+	return *res.OperationId, nil
+}
+
+// CreateStackInstances adds an instance of the stack set to each account and region
+// and returns the ID of the operation
+func (c Client) CreateStackInstances(stackSetName string, accounts, regions []string, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	req := c.api().CreateStackInstancesRequest(&cloudformation.CreateStackInstancesInput{
+		StackSetName:         &stackSetName,
+		Accounts:             accounts,
+		Regions:              regions,
+		OperationPreferences: &prefs,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return "", client.NewError(err)
+	}
+
+	return *res.OperationId, nil
+}
+
+// DeleteStackInstances removes the stack set's instances from each account and region
+// and returns the ID of the operation
+func (c Client) DeleteStackInstances(stackSetName string, accounts, regions []string, retainStacks bool, prefs cloudformation.StackSetOperationPreferences) (string, client.Error) {
+	req := c.api().DeleteStackInstancesRequest(&cloudformation.DeleteStackInstancesInput{
+		StackSetName:         &stackSetName,
+		Accounts:             accounts,
+		Regions:              regions,
+		RetainStacks:         &retainStacks,
+		OperationPreferences: &prefs,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return "", client.NewError(err)
+	}
+
+	return *res.OperationId, nil
+}
+
+func (c Client) DeleteStackSet(stackSetName string) client.Error {
+	req := c.api().DeleteStackSetRequest(&cloudformation.DeleteStackSetInput{
+		StackSetName: &stackSetName,
+	})
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+func (c Client) GetStackSetOperation(stackSetName, operationId string) (cloudformation.StackSetOperation, client.Error) {
+	req := c.api().DescribeStackSetOperationRequest(&cloudformation.DescribeStackSetOperationInput{
+		StackSetName: &stackSetName,
+		OperationId:  &operationId,
+	})
+
+	res, err := req.Send(client.Context())
+	if err != nil {
+		return cloudformation.StackSetOperation{}, client.NewError(err)
+	}
+
+	return *res.StackSetOperation, nil
+}
+
+// ListStackSetOperations returns the stack set's operations, most recent first
+func (c Client) ListStackSetOperations(stackSetName string) ([]cloudformation.StackSetOperationSummary, client.Error) {
+	operations := make([]cloudformation.StackSetOperationSummary, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().ListStackSetOperationsRequest(&cloudformation.ListStackSetOperationsInput{
+			StackSetName: &stackSetName,
+			NextToken:    nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return operations, client.NewError(err)
+		}
+
+		operations = append(operations, res.Summaries...)
+
+		if res.NextToken == nil {
+			break
+		}
+
+		nextToken = res.NextToken
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		a, b := operations[i].CreationTimestamp, operations[j].CreationTimestamp
+		return a != nil && b != nil && a.After(*b)
+	})
+
+	return operations, nil
+}
+
+func (c Client) ListStackSetOperationResults(stackSetName, operationId string) ([]cloudformation.StackSetOperationResultSummary, client.Error) {
+	results := make([]cloudformation.StackSetOperationResultSummary, 0)
+
+	var nextToken *string
+
+	for {
+		req := c.api().ListStackSetOperationResultsRequest(&cloudformation.ListStackSetOperationResultsInput{
+			StackSetName: &stackSetName,
+			OperationId:  &operationId,
+			NextToken:    nextToken,
+		})
+
+		res, err := req.Send(client.Context())
+		if err != nil {
+			return results, client.NewError(err)
+		}
+
+		results = append(results, res.Summaries...)
+
+		if res.NextToken == nil {
+			return results, nil
+		}
+
+		nextToken = res.NextToken
+	}
+}
@@ -1,19 +1,14 @@
-//go:generate bash generate/generate.sh
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"runtime"
-	"strings"
+	"sync"
 
 	"github.com/aws-cloudformation/rain/config"
 	"github.com/aws-cloudformation/rain/console"
-	"github.com/aws-cloudformation/rain/console/run"
 	"github.com/aws-cloudformation/rain/console/spinner"
 	"github.com/aws-cloudformation/rain/version"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -33,46 +28,40 @@ func MFAProvider() (string, error) {
 }
 
 var awsCfg *aws.Config
+var cfgLock sync.Mutex
 
-var defaultPython = []string{"/usr/bin/env", "python"}
+var ctxLock sync.Mutex
+var ctx, cancel = context.WithCancel(context.Background())
 
-func findAws() string {
-	path := os.Getenv("PATH")
+// Context returns the context that all AWS calls are made with.
+// It is cancelled by Interrupt.
+func Context() context.Context {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
 
-	for _, dir := range strings.Split(path, ":") {
-		bins, err := filepath.Glob(dir + "/aws")
-		if err != nil {
-			panic(err)
-		}
-
-		if len(bins) == 1 {
-			return bins[0]
-		}
-	}
-
-	return ""
+	return ctx
 }
 
-func getAwsPython() []string {
-	aws := findAws()
+// Interrupt cancels any AWS calls that are in progress
+// and causes any further calls to fail until ResetContext is called
+func Interrupt() {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
 
-	if aws == "" {
-		return defaultPython
-	}
-
-	script, err := ioutil.ReadFile(aws)
-	if err != nil {
-		config.Debugf("Couldn't load aws script: %s", err)
-		return defaultPython
-	}
+	cancel()
+}
 
-	parts := strings.Split(string(script), "\n")
+// Interrupted returns true if Interrupt has been called since the context was last reset
+func Interrupted() bool {
+	return Context().Err() != nil
+}
 
-	if strings.HasPrefix(parts[0], "#!") {
-		return strings.Split(parts[0][2:], " ")
-	}
+// ResetContext replaces a cancelled context so that AWS calls can be made again
+func ResetContext() {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
 
-	return defaultPython
+	ctx, cancel = context.WithCancel(context.Background())
 }
 
 func checkConfig(cfg aws.Config) bool {
@@ -96,59 +85,24 @@ func loadConfig() aws.Config {
 		configs = append(configs, external.WithSharedConfigProfile(config.Profile))
 	}
 
-	// Try to load just from the config
 	cfg, err = external.LoadDefaultAWSConfig(configs...)
 	if err != nil {
 		config.Debugf("Couldn't load default config: %s", err)
-	} else if checkConfig(cfg) {
-		config.Debugf("Loaded credentials from default config")
-		return cfg
-	}
-
-	// OK, let's try to load from dump_creds...
-	args := getAwsPython()
-	config.Debugf("AWS python: %s", args)
-	first := args[0]
-	args = args[1:]
-	args = append(args, "-c", credDumperPython)
-	output, err := run.Capture(first, args...)
-	config.Debugf("Cred dumper output: %s", output)
-	if err != nil {
-		config.Debugf("Couldn't run cred dumper: %s", err)
-		panic(fmt.Errorf("Unable to load AWS config"))
-	}
-
-	// This feels more horrible the further we get down...
-	var vars map[string]interface{}
-	err = json.Unmarshal([]byte(output), &vars)
-	if err != nil {
-		config.Debugf("Couldn't parse cred dumper output: %s", err)
-		panic(fmt.Errorf("Unable to load AWS config"))
-	}
-
-	// But feel free to come up with a better mechanism...
-	for key, value := range vars {
-		// For dealing with how the aws cli loads plugins
-		os.Setenv(key, fmt.Sprint(value))
-	}
-
-	// Load from the new environment variables
-	cfg, err = external.LoadDefaultAWSConfig()
-	if err != nil {
-		config.Debugf("Couldn't use cred dumper output: %s", err)
 		panic(fmt.Errorf("Unable to load AWS config"))
 	}
 
 	if !checkConfig(cfg) {
-		config.Debugf("Unusable creds from cred dumper: %s", err)
-		panic(fmt.Errorf("Unable to load AWS config"))
+		panic(fmt.Errorf("Unable to load AWS credentials"))
 	}
 
-	config.Debugf("Loaded credentials from cred dumper")
+	config.Debugf("Loaded credentials from default config")
 	return cfg
 }
 
 func Config() aws.Config {
+	cfgLock.Lock()
+	defer cfgLock.Unlock()
+
 	if awsCfg == nil {
 		spinner.Status("Loading AWS config...")
 
@@ -179,8 +133,25 @@ func Config() aws.Config {
 	return *awsCfg
 }
 
-func SetRegion(region string) {
-	awsCfg.Region = region
+// Reset discards the loaded AWS configuration so that the next call to Config
+// loads it again using the current config.Profile and config.Region
+func Reset() {
+	cfgLock.Lock()
+	defer cfgLock.Unlock()
+
+	awsCfg = nil
+}
+
+// ConfigFor returns a copy of the AWS configuration that uses region,
+// or the configured region if region is empty
+func ConfigFor(region string) aws.Config {
+	cfg := Config()
+
+	if region != "" {
+		cfg.Region = region
+	}
+
+	return cfg
 }
 
 type Error error
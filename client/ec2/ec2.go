@@ -1,7 +1,6 @@
 package ec2
 
 import (
-	"context"
 	"sort"
 
 	"github.com/aws-cloudformation/rain/client"
@@ -15,7 +14,7 @@ func getClient() *ec2.Client {
 func GetRegions() ([]string, client.Error) {
 	req := getClient().DescribeRegionsRequest(&ec2.DescribeRegionsInput{})
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
 	if err != nil {
 		return nil, client.NewError(err)
 	}
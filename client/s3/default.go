@@ -0,0 +1,27 @@
+package s3
+
+import (
+	"github.com/aws-cloudformation/rain/client"
+)
+
+// The functions below call the Default client, in the configured region
+
+func BucketExists(bucketName string) bool {
+	return Default.BucketExists(bucketName)
+}
+
+func CreateBucket(bucketName string) client.Error {
+	return Default.CreateBucket(bucketName)
+}
+
+func ObjectExists(bucketName, key string) bool {
+	return Default.ObjectExists(bucketName, key)
+}
+
+func PutObject(bucketName, key string, data []byte) client.Error {
+	return Default.PutObject(bucketName, key, data)
+}
+
+func ObjectURL(bucketName, key string) string {
+	return Default.ObjectURL(bucketName, key)
+}
@@ -1,32 +1,73 @@
 package s3
 
 import (
-	"context"
+	"bytes"
+	"fmt"
 
 	"github.com/aws-cloudformation/rain/client"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-func getClient() *s3.Client {
-	return s3.New(client.Config())
+// Client makes calls to S3 in a single region
+type Client struct {
+	region string
 }
 
-func BucketExists(bucketName string) bool {
-	req := getClient().HeadBucketRequest(&s3.HeadBucketInput{
+// Default makes calls in the configured region
+var Default = Client{}
+
+// In returns a Client that makes calls in region
+func In(region string) Client {
+	return Client{region}
+}
+
+func (c Client) api() *s3.Client {
+	return s3.New(client.ConfigFor(c.region))
+}
+
+func (c Client) BucketExists(bucketName string) bool {
+	req := c.api().HeadBucketRequest(&s3.HeadBucketInput{
 		Bucket: &bucketName,
 	})
 
-	_, err := req.Send(context.Background())
+	_, err := req.Send(client.Context())
 
 	return err == nil
 }
 
-func CreateBucket(bucketName string) client.Error {
-	req := getClient().CreateBucketRequest(&s3.CreateBucketInput{
+func (c Client) CreateBucket(bucketName string) client.Error {
+	req := c.api().CreateBucketRequest(&s3.CreateBucketInput{
 		Bucket: &bucketName,
 	})
 
-	_, err := req.Send(context.Background())
+	_, err := req.Send(client.Context())
 
 	return client.NewError(err)
 }
+
+func (c Client) ObjectExists(bucketName, key string) bool {
+	req := c.api().HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket: &bucketName,
+		Key:    &key,
+	})
+
+	_, err := req.Send(client.Context())
+
+	return err == nil
+}
+
+func (c Client) PutObject(bucketName, key string, data []byte) client.Error {
+	req := c.api().PutObjectRequest(&s3.PutObjectInput{
+		Bucket: &bucketName,
+		Key:    &key,
+		Body:   bytes.NewReader(data),
+	})
+
+	_, err := req.Send(client.Context())
+
+	return client.NewError(err)
+}
+
+func (c Client) ObjectURL(bucketName, key string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucketName, client.ConfigFor(c.region).Region, key)
+}
@@ -1,8 +1,6 @@
 package sts
 
 import (
-	"context"
-
 	"github.com/aws-cloudformation/rain/client"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
@@ -14,7 +12,7 @@ func getClient() *sts.Client {
 func GetCallerId() (sts.GetCallerIdentityOutput, client.Error) {
 	req := getClient().GetCallerIdentityRequest(nil)
 
-	res, err := req.Send(context.Background())
+	res, err := req.Send(client.Context())
 	if err != nil {
 		return sts.GetCallerIdentityOutput{}, client.NewError(err)
 	}
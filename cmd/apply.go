@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/spf13/cobra"
+)
+
+// plan is a change set that has been created by 'rain deploy --plan-out'
+// and can be executed later by 'rain apply'
+type plan struct {
+	Profile       string `json:",omitempty"`
+	Region        string
+	StackName     string
+	ChangeSetName string
+	ChangeSetId   string
+	TemplateHash  string
+	Parameters    map[string]string
+	Changes       string
+
+	// Stack settings that are applied along with the change set
+	DisableRollback       bool   `json:",omitempty"`
+	TerminationProtection *bool  `json:",omitempty"`
+	StackPolicy           string `json:",omitempty"`
+}
+
+func hashTemplate(template string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(template)))
+}
+
+func planParameters(params []cloudformation.Parameter) map[string]string {
+	out := make(map[string]string)
+
+	for _, param := range params {
+		if param.ParameterValue != nil {
+			out[*param.ParameterKey] = *param.ParameterValue
+		}
+	}
+
+	return out
+}
+
+func savePlan(fn, stackName, changeSetName, template string, changes []cloudformation.Change) {
+	changeSet, err := cfn.DescribeChangeSet(stackName, changeSetName)
+	if err != nil {
+		panic(fmt.Errorf("Error while retrieving changeset '%s': %s", changeSetName, err))
+	}
+
+	// Store the changes without colours
+	hasColour := console.HasColour
+	console.HasColour = false
+	formatted := formatChangeSet(changes)
+	console.HasColour = hasColour
+
+	p := plan{
+		Profile:       config.Profile,
+		Region:        client.Config().Region,
+		StackName:     stackName,
+		ChangeSetName: changeSetName,
+		ChangeSetId:   *changeSet.ChangeSetId,
+		TemplateHash:  hashTemplate(template),
+		Parameters:    planParameters(changeSet.Parameters),
+		Changes:       formatted,
+
+		DisableRollback: disableRollback,
+		StackPolicy:     stackPolicy,
+	}
+
+	if changeTerminationProtection {
+		p.TerminationProtection = &terminationProtection
+	}
+
+	writePlan(fn, p)
+}
+
+func writePlan(fn string, p plan) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		panic(fmt.Errorf("Unable to save plan: %s", err))
+	}
+
+	err = ioutil.WriteFile(fn, data, 0644)
+	if err != nil {
+		panic(fmt.Errorf("Unable to write plan to '%s': %s", fn, err))
+	}
+}
+
+func loadPlan(fn string) plan {
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		panic(fmt.Errorf("Unable to read plan '%s': %s", fn, err))
+	}
+
+	var p plan
+	err = json.Unmarshal(data, &p)
+	if err != nil {
+		panic(fmt.Errorf("Unable to parse plan '%s': %s", fn, err))
+	}
+
+	if p.StackName == "" || p.ChangeSetId == "" || p.Region == "" {
+		panic(fmt.Errorf("'%s' is not a rain plan file", fn))
+	}
+
+	return p
+}
+
+// usePlanTarget switches to the profile and region that the plan was created in.
+// Giving a different profile or region on the command line is an error.
+func usePlanTarget(p plan) {
+	if config.Profile != "" && config.Profile != p.Profile {
+		panic(fmt.Errorf("The plan was created with profile '%s', not '%s'", p.Profile, config.Profile))
+	}
+
+	if config.Region != "" && config.Region != p.Region {
+		panic(fmt.Errorf("The plan was created in %s, not %s", p.Region, config.Region))
+	}
+
+	config.Profile = p.Profile
+	config.Region = p.Region
+	client.Reset()
+}
+
+// checkPlan returns an error if the plan's change set has changed or can no longer be executed
+func checkPlan(p plan) error {
+	changeSet, err := cfn.DescribeChangeSet(p.StackName, p.ChangeSetId)
+	if err != nil {
+		return fmt.Errorf("Unable to find change set '%s': %s", p.ChangeSetName, err)
+	}
+
+	template, err := cfn.GetChangeSetTemplate(p.StackName, p.ChangeSetId)
+	if err != nil {
+		return fmt.Errorf("Unable to get the template for change set '%s': %s", p.ChangeSetName, err)
+	}
+
+	return comparePlan(p, changeSet, template)
+}
+
+// comparePlan returns an error if changeSet and its template don't match the plan
+func comparePlan(p plan, changeSet cloudformation.DescribeChangeSetOutput, template string) error {
+	if *changeSet.ChangeSetId != p.ChangeSetId {
+		return fmt.Errorf("Change set '%s' has been replaced", p.ChangeSetName)
+	}
+
+	if changeSet.Status != cloudformation.ChangeSetStatusCreateComplete || changeSet.ExecutionStatus != cloudformation.ExecutionStatusAvailable {
+		return fmt.Errorf("Change set '%s' can no longer be executed: %s %s", p.ChangeSetName, changeSet.Status, changeSet.ExecutionStatus)
+	}
+
+	if hashTemplate(template) != p.TemplateHash {
+		return fmt.Errorf("The template for change set '%s' does not match the plan", p.ChangeSetName)
+	}
+
+	params := planParameters(changeSet.Parameters)
+	if len(params) != len(p.Parameters) {
+		return fmt.Errorf("The parameters for change set '%s' do not match the plan", p.ChangeSetName)
+	}
+
+	for key, value := range p.Parameters {
+		if params[key] != value {
+			return fmt.Errorf("Parameter '%s' of change set '%s' does not match the plan", key, p.ChangeSetName)
+		}
+	}
+
+	return nil
+}
+
+var applyCmd = &cobra.Command{
+	Use:                   "apply <plan>",
+	Short:                 "Deploy a plan created by 'rain deploy --plan-out'",
+	Long:                  "Checks that the change set saved in <plan> still exists and has not changed, then executes it and waits for the stack to settle.",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		p := loadPlan(args[0])
+		usePlanTarget(p)
+
+		spinner.Status(fmt.Sprintf("Checking change set '%s'...", p.ChangeSetName))
+		err := checkPlan(p)
+		spinner.Stop()
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Applying plan to stack '%s' in %s.\n", p.StackName, p.Region)
+		fmt.Println("CloudFormation will make the following changes:")
+		fmt.Println(p.Changes)
+
+		if !force && !console.Confirm(true, "Do you wish to continue?") {
+			panic(errors.New("User cancelled deployment."))
+		}
+
+		disableRollback = p.DisableRollback
+		stackPolicy = p.StackPolicy
+		if p.TerminationProtection != nil {
+			changeTerminationProtection = true
+			terminationProtection = *p.TerminationProtection
+		}
+
+		executeChangeSet(p.StackName, p.ChangeSetId)
+		updateStackSettings("", p.StackName)
+	},
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&force, "force", "f", false, "Don't ask questions; just deploy.")
+	Root.AddCommand(applyCmd)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+const planTemplate = "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n"
+
+func testPlan() plan {
+	protect := true
+
+	return plan{
+		Profile:               "prod",
+		Region:                "eu-west-1",
+		StackName:             "stack",
+		ChangeSetName:         "change-set",
+		ChangeSetId:           "arn:aws:cloudformation:eu-west-1:123456789012:changeSet/change-set/1",
+		TemplateHash:          hashTemplate(planTemplate),
+		Parameters:            map[string]string{"Name": "x"},
+		Changes:               "+ AWS::S3::Bucket Bucket\n",
+		DisableRollback:       true,
+		TerminationProtection: &protect,
+	}
+}
+
+func TestSaveAndLoadPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "plan.json")
+	expected := testPlan()
+
+	writePlan(fn, expected)
+
+	actual := loadPlan(fn)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	if err := ioutil.WriteFile(fn, []byte(`{"StackName": "stack"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := catch(func() { loadPlan(fn) }); err == nil || !strings.Contains(err.Error(), "is not a rain plan file") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUsePlanTarget(t *testing.T) {
+	defer func() {
+		config.Profile = ""
+		config.Region = ""
+	}()
+
+	p := testPlan()
+
+	config.Profile = ""
+	config.Region = ""
+	usePlanTarget(p)
+	if config.Profile != "prod" || config.Region != "eu-west-1" {
+		t.Errorf("Expected prod in eu-west-1, got %s in %s", config.Profile, config.Region)
+	}
+
+	config.Region = "us-east-1"
+	if err := catch(func() { usePlanTarget(p) }); err == nil {
+		t.Error("Expected an error for a different region")
+	}
+
+	config.Region = ""
+	config.Profile = "dev"
+	if err := catch(func() { usePlanTarget(p) }); err == nil {
+		t.Error("Expected an error for a different profile")
+	}
+}
+
+func TestComparePlan(t *testing.T) {
+	p := testPlan()
+
+	changeSet := func() cloudformation.DescribeChangeSetOutput {
+		return cloudformation.DescribeChangeSetOutput{
+			ChangeSetId:     aws.String(p.ChangeSetId),
+			Status:          cloudformation.ChangeSetStatusCreateComplete,
+			ExecutionStatus: cloudformation.ExecutionStatusAvailable,
+			Parameters: []cloudformation.Parameter{
+				{ParameterKey: aws.String("Name"), ParameterValue: aws.String("x")},
+			},
+		}
+	}
+
+	if err := comparePlan(p, changeSet(), planTemplate); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if err := comparePlan(p, changeSet(), planTemplate+"Outputs: {}\n"); err == nil || !strings.Contains(err.Error(), "does not match the plan") {
+		t.Errorf("Expected a template mismatch, got %v", err)
+	}
+
+	executed := changeSet()
+	executed.ExecutionStatus = cloudformation.ExecutionStatusExecuteComplete
+	if err := comparePlan(p, executed, planTemplate); err == nil {
+		t.Error("Expected an error for an executed change set")
+	}
+
+	changed := changeSet()
+	changed.Parameters[0].ParameterValue = aws.String("y")
+	if err := comparePlan(p, changed, planTemplate); err == nil {
+		t.Error("Expected an error for changed parameters")
+	}
+}
@@ -2,18 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
 	"github.com/aws-cloudformation/rain/cfn/diff"
 	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/cfn/pkg"
 	"github.com/aws-cloudformation/rain/client"
 	"github.com/aws-cloudformation/rain/client/cfn"
 	"github.com/aws-cloudformation/rain/client/s3"
 	"github.com/aws-cloudformation/rain/client/sts"
 	"github.com/aws-cloudformation/rain/config"
 	"github.com/aws-cloudformation/rain/console"
-	"github.com/aws-cloudformation/rain/console/run"
 	"github.com/aws-cloudformation/rain/console/spinner"
 	"github.com/aws-cloudformation/rain/console/text"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
@@ -34,9 +37,31 @@ func colouriseStatus(status string) text.Text {
 	}
 }
 
+// stackResources holds the resources of each stack that has been looked at,
+// so that a stack's resources are only fetched once while its status is being shown
+type stackResources map[string][]cloudformation.StackResource
+
+// get returns the resources in the named stack.
+// Errors are ignored because they just mean that there are no resources to list,
+// e.g. when a nested stack has not been created yet
+func (r stackResources) get(stackName string) []cloudformation.StackResource {
+	if resources, ok := r[stackName]; ok {
+		return resources
+	}
+
+	resources, _ := cfn.GetStackResources(stackName)
+	r[stackName] = resources
+
+	return resources
+}
+
 func getStackOutput(stack cloudformation.Stack, onlyChanging bool) string {
-	resources, _ := cfn.GetStackResources(*stack.StackName)
-	// We ignore errors because it just means we'll list no resources
+	return formatStackOutput(stack, stackResources{}, onlyChanging)
+}
+
+// formatStackOutput describes the stack and its resources, using cache to look up the resources
+func formatStackOutput(stack cloudformation.Stack, cache stackResources, onlyChanging bool) string {
+	resources := cache.get(*stack.StackName)
 
 	out := strings.Builder{}
 
@@ -45,6 +70,10 @@ func getStackOutput(stack cloudformation.Stack, onlyChanging bool) string {
 		out.WriteString(fmt.Sprintf("  Message: %s\n", text.Yellow(*stack.StackStatusReason)))
 	}
 
+	if !onlyChanging {
+		out.WriteString(formatStackSettings(stack))
+	}
+
 	if len(stack.Parameters) > 0 {
 		out.WriteString("  Parameters:\n")
 		for _, param := range stack.Parameters {
@@ -59,45 +88,159 @@ func getStackOutput(stack cloudformation.Stack, onlyChanging bool) string {
 		}
 	}
 
-	if len(resources) > 0 {
+	if formatted := formatResources(resources, cache, onlyChanging, "    "); formatted != "" {
 		out.WriteString("  Resources:\n")
-		for _, resource := range resources {
-			if onlyChanging && resourceHasSettled(resource) {
-				// Short output
-				out.WriteString(fmt.Sprintf("    %s: %s  # %s\n",
-					*resource.LogicalResourceId,
-					text.Yellow(*resource.ResourceType),
-					colouriseStatus(string(resource.ResourceStatus)),
-				))
-			} else {
-				// Long output
-				out.WriteString(fmt.Sprintf("    %s:  # %s\n", *resource.LogicalResourceId, colouriseStatus(string(resource.ResourceStatus))))
-				out.WriteString(fmt.Sprintf("      Type: %s\n", text.Yellow(*resource.ResourceType)))
-				if resource.PhysicalResourceId != nil {
-					out.WriteString(fmt.Sprintf("      PhysicalID: %s\n", text.Yellow(*resource.PhysicalResourceId)))
-				}
-				if resource.ResourceStatusReason != nil {
-					out.WriteString(fmt.Sprintf("      Message: %s\n", text.Yellow(*resource.ResourceStatusReason)))
-				}
+		out.WriteString(formatted)
+	}
+
+	return out.String()
+}
+
+// formatStackSettings describes the options that the stack was deployed with
+func formatStackSettings(stack cloudformation.Stack) string {
+	out := strings.Builder{}
+
+	if stack.RoleARN != nil {
+		out.WriteString(fmt.Sprintf("  RoleARN: %s\n", text.Yellow(*stack.RoleARN)))
+	}
+
+	if stack.EnableTerminationProtection != nil && *stack.EnableTerminationProtection {
+		out.WriteString(fmt.Sprintf("  TerminationProtection: %s\n", text.Yellow("enabled")))
+	}
+
+	if stack.DisableRollback != nil && *stack.DisableRollback {
+		out.WriteString(fmt.Sprintf("  Rollback: %s\n", text.Yellow("disabled")))
+	}
+
+	if len(stack.NotificationARNs) > 0 {
+		out.WriteString("  NotificationARNs:\n")
+		for _, arn := range stack.NotificationARNs {
+			out.WriteString(fmt.Sprintf("    - %s\n", text.Yellow(arn)))
+		}
+	}
+
+	if rc := stack.RollbackConfiguration; rc != nil && (len(rc.RollbackTriggers) > 0 || (rc.MonitoringTimeInMinutes != nil && *rc.MonitoringTimeInMinutes > 0)) {
+		out.WriteString("  RollbackConfiguration:\n")
+		if rc.MonitoringTimeInMinutes != nil {
+			out.WriteString(fmt.Sprintf("    MonitoringTimeInMinutes: %s\n", text.Yellow(fmt.Sprint(*rc.MonitoringTimeInMinutes))))
+		}
+
+		if len(rc.RollbackTriggers) > 0 {
+			out.WriteString("    RollbackTriggers:\n")
+			for _, trigger := range rc.RollbackTriggers {
+				out.WriteString(fmt.Sprintf("      - %s\n", text.Yellow(*trigger.Arn)))
 			}
 		}
 	}
 
+	// We ignore errors because it just means we'll show no policy
+	if policy, _ := cfn.GetStackPolicy(*stack.StackName); policy != "" {
+		out.WriteString("  StackPolicy: |\n")
+		for _, line := range strings.Split(strings.TrimSpace(policy), "\n") {
+			out.WriteString(fmt.Sprintf("    %s\n", text.Yellow(line)))
+		}
+	}
+
 	return out.String()
 }
 
-func getRainBucket() string {
+func isNestedStack(resource cloudformation.StackResource) bool {
+	return *resource.ResourceType == "AWS::CloudFormation::Stack" && resource.PhysicalResourceId != nil && *resource.PhysicalResourceId != ""
+}
+
+// formatResources lists resources, including the contents of any nested stacks,
+// leaving out those that have settled if onlyChanging is set
+func formatResources(resources []cloudformation.StackResource, cache stackResources, onlyChanging bool, indent string) string {
+	out := strings.Builder{}
+
+	for _, resource := range resources {
+		if onlyChanging && resourceHasSettled(resource) {
+			continue
+		}
+
+		// Long output
+		out.WriteString(fmt.Sprintf("%s%s:  # %s\n", indent, *resource.LogicalResourceId, colouriseStatus(string(resource.ResourceStatus))))
+		out.WriteString(fmt.Sprintf("%s  Type: %s\n", indent, text.Yellow(*resource.ResourceType)))
+		if resource.PhysicalResourceId != nil {
+			out.WriteString(fmt.Sprintf("%s  PhysicalID: %s\n", indent, text.Yellow(*resource.PhysicalResourceId)))
+		}
+		if resource.ResourceStatusReason != nil {
+			out.WriteString(fmt.Sprintf("%s  Message: %s\n", indent, text.Yellow(*resource.ResourceStatusReason)))
+		}
+
+		if isNestedStack(resource) {
+			nested := formatResources(cache.get(*resource.PhysicalResourceId), cache, onlyChanging, indent+"    ")
+
+			if nested != "" {
+				out.WriteString(fmt.Sprintf("%s  Resources:\n", indent))
+				out.WriteString(nested)
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// countUpdating returns the number of resources that have not settled,
+// including those in nested stacks
+func countUpdating(resources []cloudformation.StackResource, cache stackResources) int {
+	updating := 0
+
+	for _, resource := range resources {
+		if resourceHasSettled(resource) {
+			continue
+		}
+
+		if isNestedStack(resource) {
+			nested := cache.get(*resource.PhysicalResourceId)
+			if n := countUpdating(nested, cache); n > 0 {
+				updating += n
+				continue
+			}
+		}
+
+		updating++
+	}
+
+	return updating
+}
+
+func parseTags(tags []string) map[string]string {
+	parsedTags := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+
+		if len(parts) != 2 {
+			panic(fmt.Errorf("Unable to parse tag: %s", tag))
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if _, ok := parsedTags[key]; ok {
+			panic(fmt.Errorf("Duplicate tag: %s", key))
+		}
+
+		parsedTags[key] = value
+	}
+
+	return parsedTags
+}
+
+// getRainBucket returns the name of rain's artifact bucket in region, creating it if necessary.
+// If region is empty, the configured region is used.
+func getRainBucket(region string) string {
 	accountId, err := sts.GetAccountId()
 	if err != nil {
 		panic(fmt.Errorf("Unable to get account ID: %s", err))
 	}
 
-	bucketName := fmt.Sprintf("rain-artifacts-%s-%s", accountId, client.Config().Region)
+	bucketName := fmt.Sprintf("rain-artifacts-%s-%s", accountId, client.ConfigFor(region).Region)
 
 	config.Debugf("Artifact bucket: %s", bucketName)
 
-	if !s3.BucketExists(bucketName) {
-		err := s3.CreateBucket(bucketName)
+	if !s3.In(region).BucketExists(bucketName) {
+		err := s3.In(region).CreateBucket(bucketName)
 		if err != nil {
 			panic(fmt.Errorf("Unable to create artifact bucket '%s': %s", bucketName, err))
 		}
@@ -106,6 +249,63 @@ func getRainBucket() string {
 	return bucketName
 }
 
+// artifactStore uploads packaged artifacts to an S3 bucket
+type artifactStore struct {
+	api    s3.Client
+	bucket string
+}
+
+func newArtifactStore(bucket, region string) artifactStore {
+	return artifactStore{s3.In(region), bucket}
+}
+
+func (s artifactStore) Bucket() string {
+	return s.bucket
+}
+
+func (s artifactStore) URL(key string) string {
+	return s.api.ObjectURL(s.bucket, key)
+}
+
+func (s artifactStore) Exists(key string) bool {
+	if s.api.ObjectExists(s.bucket, key) {
+		config.Debugf("Artifact already uploaded: s3://%s/%s", s.bucket, key)
+		return true
+	}
+
+	return false
+}
+
+func (s artifactStore) Put(key string, data []byte) error {
+	config.Debugf("Uploading artifact: s3://%s/%s", s.bucket, key)
+
+	err := s.api.PutObject(s.bucket, key, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// packageTemplate uploads the template's local artifacts to bucket in region
+// and returns the packaged template along with its formatted source.
+// source is left unchanged so that it can be packaged again for other regions.
+func packageTemplate(fn string, source cfnTemplate.Template, bucket, region string) (cfnTemplate.Template, string) {
+	packaged, err := pkg.Template(source.Copy(), filepath.Dir(fn), newArtifactStore(bucket, region))
+	if err != nil {
+		panic(fmt.Errorf("Unable to package template: %s", err))
+	}
+
+	template := format.Template(packaged, format.Options{})
+
+	err = parse.Verify(packaged, template)
+	if err != nil {
+		panic(fmt.Errorf("Unable to package template: %s", err))
+	}
+
+	return packaged, template
+}
+
 func colouriseDiff(d diff.Diff, longFormat bool) string {
 	output := strings.Builder{}
 
@@ -144,7 +344,10 @@ func waitForStackToSettle(stackName string) string {
 		// Refresh the stack ID so we can deal with deleted stacks ok
 		stackId = *stack.StackId
 
-		output := getStackOutput(stack, true)
+		// Fetch each stack's resources once per poll
+		cache := stackResources{}
+
+		output := formatStackOutput(stack, cache, true)
 
 		// Send the output first
 		if console.IsTTY {
@@ -153,13 +356,7 @@ func waitForStackToSettle(stackName string) string {
 		}
 
 		// Figure out how many are complete
-		updating := 0
-		resources, _ := cfn.GetStackResources(*stack.StackName)
-		for _, resource := range resources {
-			if !resourceHasSettled(resource) {
-				updating++
-			}
-		}
+		updating := countUpdating(cache.get(*stack.StackName), cache)
 		if updating > 0 {
 			rs := "resources"
 			if updating == 1 {
@@ -176,7 +373,11 @@ func waitForStackToSettle(stackName string) string {
 			return string(stack.StackStatus)
 		}
 
-		time.Sleep(time.Second * 2)
+		// Stop waiting early if the user presses Ctrl-C
+		select {
+		case <-client.Context().Done():
+		case <-time.After(time.Second * 2):
+		}
 	}
 }
 
@@ -194,15 +395,3 @@ func stackHasSettled(stack cloudformation.Stack) bool {
 func resourceHasSettled(resource cloudformation.StackResource) bool {
 	return statusIsSettled(string(resource.ResourceStatus))
 }
-
-func runAws(args ...string) (string, error) {
-	if config.Profile != "" {
-		args = append(args, "--profile", config.Profile)
-	}
-
-	if config.Region != "" {
-		args = append(args, "--region", config.Region)
-	}
-
-	return run.Capture("aws", args...)
-}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func stackResource(id, resourceType, physicalId string, status cloudformation.ResourceStatus) cloudformation.StackResource {
+	return cloudformation.StackResource{
+		LogicalResourceId:  aws.String(id),
+		ResourceType:       aws.String(resourceType),
+		PhysicalResourceId: aws.String(physicalId),
+		ResourceStatus:     status,
+	}
+}
+
+func TestStackResourcesCache(t *testing.T) {
+	// Filling the cache means that nothing is fetched from CloudFormation
+	cache := stackResources{
+		"parent": {
+			stackResource("Bucket", "AWS::S3::Bucket", "bucket", cloudformation.ResourceStatusUpdateInProgress),
+			stackResource("Child", "AWS::CloudFormation::Stack", "child", cloudformation.ResourceStatusUpdateInProgress),
+			stackResource("Queue", "AWS::SQS::Queue", "queue", cloudformation.ResourceStatusCreateComplete),
+		},
+		"child": {
+			stackResource("Topic", "AWS::SNS::Topic", "topic", cloudformation.ResourceStatusUpdateInProgress),
+			stackResource("Table", "AWS::DynamoDB::Table", "table", cloudformation.ResourceStatusCreateInProgress),
+		},
+	}
+
+	if n := countUpdating(cache.get("parent"), cache); n != 3 {
+		t.Errorf("Expected 3 resources to be updating, got %d", n)
+	}
+
+	output := formatResources(cache.get("parent"), cache, true, "")
+	for _, id := range []string{"Bucket", "Child", "Topic", "Table"} {
+		if !strings.Contains(output, id) {
+			t.Errorf("Expected %s in the output:\n%s", id, output)
+		}
+	}
+
+	if strings.Contains(output, "Queue") {
+		t.Errorf("Settled resources should be left out of the output:\n%s", output)
+	}
+
+	if output := formatResources(cache.get("parent"), cache, false, ""); !strings.Contains(output, "Queue") {
+		t.Errorf("Expected Queue in the full output:\n%s", output)
+	}
+
+	if len(cache) != 2 {
+		t.Errorf("Unexpected stacks in the cache: %v", cache)
+	}
+}
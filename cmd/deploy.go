@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strings"
 
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
 	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws-cloudformation/rain/cfn/meta"
 	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/cfn/spec"
 	"github.com/aws-cloudformation/rain/client"
 	"github.com/aws-cloudformation/rain/client/cfn"
 	"github.com/aws-cloudformation/rain/config"
@@ -22,148 +26,372 @@ import (
 
 var force = false
 var tags []string
+var lintDeploy = false
+var paramsFile = ""
+var paramFlags []string
+var planOut = ""
+var dryRun = false
+var roleArn = ""
+var notificationArns []string
+var rollbackAlarms []string
+var monitoringTime = 0
+var disableRollback = false
+var terminationProtection = false
+var stackPolicyFile = ""
+var stripMetadata = false
+
+// Settings that can't be made with a change set and are applied once the stack has deployed
+var changeTerminationProtection = false
+var stackPolicy = ""
+
+func stackOptions() cfn.StackOptions {
+	return cfn.StackOptions{
+		RoleARN:          roleArn,
+		NotificationARNs: notificationArns,
+		RollbackAlarms:   rollbackAlarms,
+		MonitoringTime:   int64(monitoringTime),
+	}
+}
+
+// loadStackSettings reads the settings that are applied after deployment
+func loadStackSettings(cmd *cobra.Command) {
+	changeTerminationProtection = cmd.Flags().Changed("termination-protection")
+
+	if stackPolicyFile == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(stackPolicyFile)
+	if err != nil {
+		panic(fmt.Errorf("Unable to read stack policy '%s': %s", stackPolicyFile, err))
+	}
+
+	if !json.Valid(data) {
+		panic(fmt.Errorf("Stack policy '%s' is not valid JSON", stackPolicyFile))
+	}
+
+	stackPolicy = string(data)
+}
+
+// updateStackSettings applies termination protection and the stack policy
+func updateStackSettings(region, stackName string) {
+	if changeTerminationProtection {
+		err := cfn.In(region).SetTerminationProtection(stackName, terminationProtection)
+		if err != nil {
+			panic(fmt.Errorf("Unable to set termination protection for stack '%s': %s", stackName, err))
+		}
+	}
+
+	if stackPolicy != "" {
+		err := cfn.In(region).SetStackPolicy(stackName, stackPolicy)
+		if err != nil {
+			panic(fmt.Errorf("Unable to set the stack policy for stack '%s': %s", stackName, err))
+		}
+	}
+}
+
+// This version of the SDK predates resource import
+const changeActionImport = cloudformation.ChangeAction("Import")
+
+func formatChangeDetail(detail cloudformation.ResourceChangeDetail) string {
+	target := string(detail.Target.Attribute)
+	if detail.Target.Name != nil {
+		target += "." + *detail.Target.Name
+	}
+
+	reasons := make([]string, 0)
+
+	if detail.ChangeSource != "" {
+		reason := string(detail.ChangeSource)
+		if detail.CausingEntity != nil {
+			reason += ": " + *detail.CausingEntity
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if detail.Evaluation == cloudformation.EvaluationTypeDynamic {
+		reasons = append(reasons, "may change")
+	}
+
+	switch detail.Target.RequiresRecreation {
+	case cloudformation.RequiresRecreationAlways:
+		reasons = append(reasons, text.Red("requires replacement").String())
+	case cloudformation.RequiresRecreationConditionally:
+		reasons = append(reasons, text.Orange("may require replacement").String())
+	}
+
+	if len(reasons) == 0 {
+		return target
+	}
+
+	return fmt.Sprintf("%s (%s)", target, strings.Join(reasons, ", "))
+}
 
 func formatChangeSet(changes []cloudformation.Change) string {
 	out := strings.Builder{}
 
+	var adds, modifies, replaces, removes, imports int
+
 	for _, change := range changes {
-		line := fmt.Sprintf("%s %s\n",
-			*change.ResourceChange.ResourceType,
-			*change.ResourceChange.LogicalResourceId,
-		)
+		rc := change.ResourceChange
+		if rc == nil || rc.Action == "" {
+			continue
+		}
 
-		switch change.ResourceChange.Action {
-		case cloudformation.ChangeAction("Add"):
+		line := fmt.Sprintf("%s %s", *rc.ResourceType, *rc.LogicalResourceId)
+		stateful := spec.IsStateful(*rc.ResourceType)
+
+		switch rc.Action {
+		case cloudformation.ChangeActionAdd:
+			adds++
 			out.WriteString(text.Green("(+) " + line).String())
-		case cloudformation.ChangeAction("Modify"):
-			out.WriteString(text.Orange("(|) " + line).String())
-		case cloudformation.ChangeAction("Remove"):
+		case cloudformation.ChangeActionModify:
+			modifies++
+
+			switch rc.Replacement {
+			case cloudformation.ReplacementTrue:
+				replaces++
+				out.WriteString(text.Red("(!) " + line + " [replacement]").String())
+			case cloudformation.ReplacementConditional:
+				replaces++
+				out.WriteString(text.Orange("(!) " + line + " [conditional replacement]").String())
+			default:
+				out.WriteString(text.Orange("(|) " + line).String())
+			}
+
+			if stateful && rc.Replacement != cloudformation.ReplacementFalse {
+				out.WriteString(" " + text.Red("<- data may be lost").String())
+			}
+		case changeActionImport:
+			imports++
+			out.WriteString(text.Green("(<) " + line + " [import]").String())
+		case cloudformation.ChangeActionRemove:
+			removes++
 			out.WriteString(text.Red("(-) " + line).String())
+
+			if stateful {
+				out.WriteString(" " + text.Red("<- data may be lost").String())
+			}
+		default:
+			out.WriteString(text.Grey("(?) " + line).String())
 		}
+		out.WriteString("\n")
+
+		if len(rc.Scope) > 0 {
+			scope := make([]string, len(rc.Scope))
+			for i, attribute := range rc.Scope {
+				scope[i] = string(attribute)
+			}
+			out.WriteString(text.Grey(fmt.Sprintf("    Scope: %s\n", strings.Join(scope, ", "))).String())
+		}
+
+		for _, detail := range rc.Details {
+			if detail.Target == nil {
+				continue
+			}
+
+			out.WriteString(fmt.Sprintf("    - %s\n", formatChangeDetail(detail)))
+		}
+	}
+
+	out.WriteString(fmt.Sprintf("\n%d to add, %d to modify (%d may be replaced), %d to remove", adds, modifies, replaces, removes))
+	if imports > 0 {
+		out.WriteString(fmt.Sprintf(", %d to import", imports))
 	}
+	out.WriteString("\n")
 
 	return out.String()
 }
 
-func getParameters(t string, old []cloudformation.Parameter, forceOldValue bool) []cloudformation.Parameter {
-	newParams := make([]cloudformation.Parameter, 0)
+// grantCapabilities shows the capabilities that the template requires, along with any that its settings request,
+// and asks the user to grant them. It returns the names of the capabilities to use.
+func grantCapabilities(template cfnTemplate.Template, baseDir string, settings []string) []string {
+	requested := requestCapabilities(capabilities.RequiredIn(template, baseDir), settings)
 
-	template, err := parse.String(t)
-	if err != nil {
-		panic(fmt.Errorf("Unable to parse template: %s", err))
-	}
+	if len(requested) > 0 {
+		fmt.Println("This template requires the following capabilities:")
+		fmt.Print(formatCapabilities(requested))
 
-	oldMap := make(map[string]cloudformation.Parameter)
-	for _, param := range old {
-		oldMap[*param.ParameterKey] = param
+		if !force && !dryRun && !console.Confirm(true, "Do you wish to grant these capabilities?") {
+			panic(errors.New("User cancelled deployment."))
+		}
 	}
 
-	if params, ok := template.Map()["Parameters"]; ok {
-		for k, p := range params.(map[string]interface{}) {
-			// New variable so we don't mess up the pointers below
-			key := k
-
-			extra := ""
-			param := p.(map[string]interface{})
+	return capabilities.Names(requested)
+}
 
-			hasExisting := false
+// requestCapabilities adds the capabilities listed in the template's settings to those that it requires
+func requestCapabilities(required []capabilities.Capability, settings []string) []capabilities.Capability {
+	reason := fmt.Sprintf("requested by Metadata.%s.Capabilities", meta.Key)
 
-			value := ""
+	out := make([]capabilities.Capability, len(required))
+	copy(out, required)
 
-			if oldParam, ok := oldMap[key]; ok {
-				extra = fmt.Sprintf(" (existing value: %s)", fmt.Sprint(*oldParam.ParameterValue))
-				hasExisting = true
+	for _, name := range settings {
+		found := false
 
-				if forceOldValue {
-					value = *oldParam.ParameterValue
-				}
-			} else if defaultValue, ok := param["Default"]; ok {
-				extra = fmt.Sprintf(" (default value: %s)", fmt.Sprint(defaultValue))
+		for i, capability := range out {
+			if capability.Name == name {
+				out[i].Reasons = append(append([]string{}, capability.Reasons...), reason)
+				found = true
 			}
+		}
 
-			if force {
-				panic(fmt.Errorf("Some parameters require values. Set defaults or deploy without the --force flag."))
-			}
+		if !found {
+			out = append(out, capabilities.Capability{Name: name, Reasons: []string{reason}})
+		}
+	}
 
-			newValue := console.Ask(fmt.Sprintf("Enter a value for parameter '%s'%s:", key, extra))
-
-			if newValue != "" {
-				newParams = append(newParams, cloudformation.Parameter{
-					ParameterKey:   &key,
-					ParameterValue: &newValue,
-				})
-			} else if value != "" && forceOldValue {
-				newParams = append(newParams, cloudformation.Parameter{
-					ParameterKey:   &key,
-					ParameterValue: &value,
-				})
-			} else if hasExisting {
-				newParams = append(newParams, cloudformation.Parameter{
-					ParameterKey:     &key,
-					UsePreviousValue: &hasExisting,
-				})
-			}
+	return out
+}
+
+func formatCapabilities(required []capabilities.Capability) string {
+	out := strings.Builder{}
+
+	for _, capability := range required {
+		out.WriteString(fmt.Sprintf("  %s:\n", text.Yellow(capability.Name)))
+		for _, reason := range capability.Reasons {
+			out.WriteString(fmt.Sprintf("    - %s\n", reason))
 		}
 	}
 
-	return newParams
+	return out.String()
 }
 
 var deployCmd = &cobra.Command{
-	Use:                   "deploy <template> <stack>",
-	Short:                 "Deploy a CloudFormation stack from a local template",
-	Long:                  "Creates or updates a CloudFormation stack named <stack> from the template file <template>.",
-	Args:                  cobra.ExactArgs(2),
+	Use:   "deploy <template> [stack]",
+	Short: "Deploy a CloudFormation stack from a local template",
+	Long: `Creates or updates a CloudFormation stack named <stack> from the template file <template>.
+
+The template may contain default settings for the deployment in its Metadata section.
+Command line arguments and flags take precedence over these settings:
+
+  Metadata:
+    Rain:
+      StackName: my-stack
+      Region: eu-west-1
+      Capabilities:
+        - CAPABILITY_IAM
+      Parameters:
+        Name: Value
+      Tags:
+        Key: Value
+
+If the template sets a stack name, <stack> may be omitted.
+
+With --regions, deploys the stack to each of the given regions in parallel,
+showing the changes for every region before asking for a single confirmation.
+
+With --file, deploys every stack described in a rain.yaml manifest instead.
+Each wave's change sets are created and shown before asking for confirmation.
+The stack options --role-arn, --notification-arns, --rollback-alarms, --monitoring-time,
+--disable-rollback, --termination-protection and --stack-policy apply to every stack in the manifest.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if manifestFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	},
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
-		fn := args[0]
-		stackName := args[1]
+		loadStackSettings(cmd)
 
-		// Parse tags
-		parsedTags := make(map[string]string, len(tags))
-		for _, tag := range tags {
-			parts := strings.SplitN(tag, "=", 2)
-
-			if len(parts) != 2 {
-				panic(fmt.Errorf("Unable to parse tag: %s", tag))
+		if manifestFile != "" {
+			if len(deployRegions) > 0 {
+				panic(errors.New("--file and --regions cannot be used together"))
 			}
 
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
+			if dryRun {
+				panic(errors.New("--file and --dry-run cannot be used together"))
+			}
 
-			if _, ok := parsedTags[key]; ok {
-				panic(fmt.Errorf("Duplicate tag: %s", key))
+			if planOut != "" {
+				panic(errors.New("--file and --plan-out cannot be used together"))
 			}
 
-			parsedTags[key] = value
+			deployManifest(manifestFile)
+			return
 		}
 
-		fmt.Printf("Deploying '%s' as '%s' in %s:\n", filepath.Base(fn), stackName, client.Config().Region)
+		fn := args[0]
 
-		fmt.Print("Preparing template... ")
+		if dryRun && planOut != "" {
+			panic(errors.New("--dry-run and --plan-out cannot be used together"))
+		}
 
-		outputFn, err := ioutil.TempFile("", "")
+		source, err := parse.File(fn)
 		if err != nil {
-			panic(err)
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
 		}
-		defer func() {
-			config.Debugf("Removing temporary template file: %s", outputFn.Name())
-			err := os.Remove(outputFn.Name())
-			if err != nil {
-				panic(fmt.Errorf("Error removing temporary template file '%s': %s", outputFn.Name(), err))
-			}
-		}()
 
-		output, err := runAws("cloudformation", "package",
-			"--template-file", fn,
-			"--output-template-file", outputFn.Name(),
-			"--s3-bucket", getRainBucket(),
-		)
+		settings, err := meta.Read(source)
 		if err != nil {
-			panic(fmt.Errorf("Unable to package template: %s", err))
+			panic(fmt.Errorf("Unable to read settings from template '%s': %s", fn, err))
+		}
+
+		stackName := settings.StackName
+		if len(args) > 1 {
+			stackName = args[1]
+		}
+
+		if stackName == "" {
+			panic(fmt.Errorf("No stack name given. Pass one as an argument or set Metadata.%s.StackName in '%s'", meta.Key, fn))
+		}
+
+		if config.Region == "" {
+			config.Region = settings.Region
+		}
+
+		d := &deployment{stackName: stackName}
+		handleInterrupts(true)
+		defer d.handleInterrupt()
+
+		// Parse parameters and tags; flags take precedence over files, and files over the template's settings
+		paramValues, fileTags := getParameterValues(source)
+		for key, value := range settings.Parameters {
+			if _, ok := paramValues[key]; !ok {
+				paramValues[key] = value
+			}
+		}
+
+		parsedTags := parseTags(tags)
+		for _, defaults := range []map[string]string{fileTags, settings.Tags} {
+			for key, value := range defaults {
+				if _, ok := parsedTags[key]; !ok {
+					parsedTags[key] = value
+				}
+			}
 		}
 
-		config.Debugf("Package output: %s", output)
+		var provenanceAdded map[string]bool
+		if provenance {
+			provenanceAdded = addProvenanceTags(filepath.Dir(fn), parsedTags)
+		}
+
+		if lintDeploy && !lintTemplate(source) {
+			panic(fmt.Errorf("Template '%s' has errors; not deploying", fn))
+		}
+
+		if stripMetadata {
+			source = meta.Strip(source)
+		}
+
+		if len(deployRegions) > 0 {
+			capabilityNames := grantCapabilities(source, filepath.Dir(fn), settings.Capabilities)
+			deployToRegions(fn, stackName, source, paramValues, parsedTags, provenanceAdded, capabilityNames)
+			return
+		}
+
+		fmt.Printf("Deploying '%s' as '%s' in %s:\n", filepath.Base(fn), stackName, client.Config().Region)
+
+		fmt.Print("Preparing template... ")
+
+		bucket := getRainBucket("")
+
+		parsedTemplate, template := packageTemplate(fn, source, bucket, "")
+
+		config.Debugf("Packaged template:\n%s", template)
 
 		console.ClearLine()
 		fmt.Printf("Checking current status of stack '%s'... ", stackName)
@@ -178,12 +406,23 @@ var deployCmd = &cobra.Command{
 			stackExists = true
 		}
 
+		if stackExists {
+			if action := recoveryAction(string(stack.StackStatus)); action != "" && dryRun {
+				fmt.Printf("Stack is currently %s; deploying will %s.\n", stack.StackStatus, action)
+				ExitCode = exitChanges
+				return
+			}
+
+			console.ClearLine()
+			stack, stackExists = recoverStack(stack)
+		}
+
 		if stackExists {
 			if string(stack.StackStatus) == "ROLLBACK_COMPLETE" {
 				forceOldParams = true
 
 				fmt.Println("Stack is currently ROLLBACK_COMPLETE; deleting...")
-				err := cfn.DeleteStack(stackName)
+				err := cfn.DeleteStack(stackName, roleArn)
 				if err != nil {
 					panic(fmt.Errorf("Unable to delete stack '%s': %s", stackName, err))
 				}
@@ -193,10 +432,12 @@ var deployCmd = &cobra.Command{
 				if status != "DELETE_COMPLETE" {
 					panic(fmt.Errorf("Failed to delete " + stackName))
 				}
+
+				stackExists = false
 			} else if !strings.HasSuffix(string(stack.StackStatus), "_COMPLETE") {
 				// Can't update
 				panic(fmt.Errorf("Stack '%s' could not be updated: %s", stackName, colouriseStatus(string(stack.StackStatus))))
-			} else if !force {
+			} else if !force || dryRun {
 				// Can update, grab a diff
 
 				oldTemplateString, err := cfn.GetStackTemplate(stackName, false)
@@ -205,9 +446,8 @@ var deployCmd = &cobra.Command{
 				}
 
 				oldTemplate, _ := parse.String(oldTemplateString)
-				newTemplate, _ := parse.File(outputFn.Name())
 
-				d := oldTemplate.Diff(newTemplate)
+				d := oldTemplate.Diff(parsedTemplate)
 
 				if d.Mode() == diff.Unchanged {
 					fmt.Println(text.Green("No changes to deploy!"))
@@ -215,27 +455,35 @@ var deployCmd = &cobra.Command{
 				}
 
 				console.ClearLine()
-				if console.Confirm(true, fmt.Sprintf("Stack '%s' exists. Do you wish to compare the CloudFormation templates?", stackName)) {
+				if !dryRun && console.Confirm(true, fmt.Sprintf("Stack '%s' exists. Do you wish to compare the CloudFormation templates?", stackName)) {
 					fmt.Print(colouriseDiff(d, false))
 				}
 			}
 		}
 
-		// Load in the template file
-		t, err := ioutil.ReadFile(outputFn.Name())
-		if err != nil {
-			panic(fmt.Errorf("Can't load template '%s': %s", outputFn.Name(), err))
-		}
-		template := string(t)
+		d.stackExists = stackExists
 
-		parameters := getParameters(template, stack.Parameters, forceOldParams)
+		parameters := getParameters(parsedTemplate, paramValues, stack.Parameters, forceOldParams, !force && !dryRun)
 
 		config.Debugf("Parameters: %s", parameters)
 
+		if provenance && stackExists && !forceOldParams {
+			keepProvenanceTags("", stack, parsedTemplate, parameters, parsedTags, provenanceAdded)
+		}
+
+		// Work out which capabilities are needed
+		capabilityNames := grantCapabilities(source, filepath.Dir(fn), settings.Capabilities)
+
 		// Create a change set
 		spinner.Status("Creating change set...")
-		changeSetName, err := cfn.CreateChangeSet(template, parameters, parsedTags, stackName)
-		if err != nil {
+		changeSetName, err := cfn.CreateChangeSet(template, parameters, parsedTags, stackName, capabilityNames, bucket, stackOptions())
+		d.changeSetName = changeSetName
+		if err != nil && dryRun && changeSetIsEmpty("", stackName, changeSetName) {
+			spinner.Stop()
+			cancelChangeSet("", stackName, changeSetName, stackExists)
+			fmt.Println(text.Green("No changes to deploy!"))
+			return
+		} else if err != nil {
 			panic(fmt.Errorf("Error while creating changeset for '%s': %s", stackName, err))
 		}
 		changes, err := cfn.GetChangeSet(stackName, changeSetName)
@@ -247,43 +495,108 @@ var deployCmd = &cobra.Command{
 		fmt.Println("CloudFormation will make the following changes:")
 		fmt.Println(formatChangeSet(changes))
 
-		if !force && !console.Confirm(true, "Do you wish to continue?") {
-			err = cfn.DeleteChangeSet(stackName, changeSetName)
-			if err != nil {
-				panic(fmt.Errorf("Error while deleting changeset '%s': %s", changeSetName, err))
-			}
+		if planOut != "" {
+			savePlan(planOut, stackName, changeSetName, template, changes)
+			fmt.Printf("Saved plan to '%s'. Run 'rain apply %s' to deploy it.\n", planOut, planOut)
+			return
+		}
 
-			if !stackExists {
-				err = cfn.DeleteStack(stackName)
-				if err != nil {
-					panic(fmt.Errorf("Error deleting empty stack '%s': %s", stackName, err))
-				}
+		if dryRun {
+			cancelChangeSet("", stackName, changeSetName, stackExists)
+
+			if len(changes) > 0 {
+				ExitCode = exitChanges
 			}
 
+			fmt.Println("Dry run; nothing was deployed.")
+			return
+		}
+
+		if !force && !console.Confirm(true, "Do you wish to continue?") {
+			cancelChangeSet("", stackName, changeSetName, stackExists)
 			panic(errors.New("User cancelled deployment."))
-		} else {
-			err = cfn.ExecuteChangeSet(stackName, changeSetName)
-			if err != nil {
-				panic(fmt.Errorf("Error while executing changeset '%s': %s", changeSetName, err))
-			}
 		}
 
-		status := waitForStackToSettle(stackName)
+		d.executing = true
+		executeChangeSet(stackName, changeSetName)
 
-		if status == "CREATE_COMPLETE" {
-			fmt.Println(text.Green("Successfully deployed " + stackName))
-		} else if status == "UPDATE_COMPLETE" {
-			fmt.Println(text.Green("Successfully updated " + stackName))
-		} else {
-			panic(errors.New("Failed deployment: " + stackName))
+		// There is nothing left to clean up
+		handleInterrupts(false)
+		updateStackSettings("", stackName)
+	},
+}
+
+// cancelChangeSet deletes a change set that won't be executed,
+// along with the placeholder stack if the stack didn't already exist
+func cancelChangeSet(region, stackName, changeSetName string, stackExists bool) {
+	err := cfn.In(region).DeleteChangeSet(stackName, changeSetName)
+	if err != nil {
+		panic(fmt.Errorf("Error while deleting changeset '%s': %s", changeSetName, err))
+	}
+
+	if !stackExists {
+		err = cfn.In(region).DeleteStack(stackName, roleArn)
+		if err != nil {
+			panic(fmt.Errorf("Error deleting empty stack '%s': %s", stackName, err))
 		}
+	}
+}
 
-		fmt.Println()
-	},
+// changeSetIsEmpty returns true if a change set failed because there was nothing to change
+func changeSetIsEmpty(region, stackName, changeSetName string) bool {
+	changeSet, err := cfn.In(region).DescribeChangeSet(stackName, changeSetName)
+	if err != nil || changeSet.Status != cloudformation.ChangeSetStatusFailed || changeSet.StatusReason == nil {
+		return false
+	}
+
+	reason := *changeSet.StatusReason
+
+	return strings.Contains(reason, "didn't contain changes") || strings.Contains(reason, "No updates are to be performed")
+}
+
+// executeChangeSet executes a change set and waits for the stack to settle
+func executeChangeSet(stackName, changeSetName string) {
+	err := cfn.ExecuteChangeSet(stackName, changeSetName, disableRollback)
+	if err != nil {
+		panic(fmt.Errorf("Error while executing changeset '%s': %s", changeSetName, err))
+	}
+
+	status := waitForStackToSettle(stackName)
+
+	if status == "CREATE_COMPLETE" {
+		fmt.Println(text.Green("Successfully deployed " + stackName))
+	} else if status == "UPDATE_COMPLETE" {
+		fmt.Println(text.Green("Successfully updated " + stackName))
+	} else if status == "IMPORT_COMPLETE" {
+		fmt.Println(text.Green("Successfully imported resources into " + stackName))
+	} else {
+		fmt.Print(diagnoseFailure("", stackName))
+		panic(errors.New("Failed deployment: " + stackName))
+	}
+
+	fmt.Println()
 }
 
 func init() {
 	deployCmd.Flags().BoolVarP(&force, "force", "f", false, "Don't ask questions; just deploy.")
+	deployCmd.Flags().StringVarP(&manifestFile, "file", "m", "", "Deploy all of the stacks described in a rain.yaml manifest. Stack options such as --role-arn apply to every stack.")
 	deployCmd.Flags().StringSliceVar(&tags, "tags", []string{}, "Add tags to the stack. Use the format key1=value1,key2=value2.")
+	deployCmd.Flags().StringVar(&paramsFile, "params", "", "Read parameter values from a file. The file may be a CloudFormation parameters file, a CodePipeline template configuration file, or a YAML map of parameter names to values.")
+	deployCmd.Flags().StringArrayVar(&paramFlags, "param", []string{}, "Set a parameter value. Use the format key=value. May be repeated.")
+	deployCmd.Flags().BoolVar(&lintDeploy, "lint", false, "Check the template with 'rain lint' first and refuse to deploy if it has errors.")
+	deployCmd.Flags().StringVar(&planOut, "plan-out", "", "Create the change set and save it as a plan file instead of deploying it. Use 'rain apply' to deploy the plan later.")
+	deployCmd.Flags().StringVar(&roleArn, "role-arn", "", "The ARN of an IAM role that CloudFormation uses to deploy the stack.")
+	deployCmd.Flags().StringSliceVar(&notificationArns, "notification-arns", []string{}, "SNS topics to send stack events to. Use the format arn1,arn2.")
+	deployCmd.Flags().StringSliceVar(&rollbackAlarms, "rollback-alarms", []string{}, "CloudWatch alarms that roll back the deployment if they go into ALARM. Use the format arn1,arn2.")
+	deployCmd.Flags().IntVar(&monitoringTime, "monitoring-time", 0, "The number of minutes to monitor the rollback alarms for after the stack has deployed.")
+	deployCmd.Flags().BoolVar(&disableRollback, "disable-rollback", false, "Keep successfully created or updated resources if the deployment fails.")
+	deployCmd.Flags().BoolVar(&terminationProtection, "termination-protection", false, "Enable termination protection for the stack. Use --termination-protection=false to disable it.")
+	deployCmd.Flags().StringVar(&stackPolicyFile, "stack-policy", "", "Set the stack policy from a JSON file once the stack has deployed.")
+	deployCmd.Flags().BoolVar(&stripMetadata, "strip-metadata", false, fmt.Sprintf("Remove the Metadata.%s settings from the template before deploying it.", meta.Key))
+	deployCmd.Flags().BoolVar(&provenance, "provenance", false, "Tag the stack with the template's git commit, branch, dirty state and repository, the deploying IAM identity, and the version of rain.")
+	deployCmd.Flags().StringToStringVar(&provenanceTagFlags, "provenance-tags", map[string]string{}, "Rename the tags added by --provenance. Use the format commit=Name,branch=Name,dirty=Name,repo=Name,identity=Name,version=Name. Set a name to nothing to leave that tag out.")
+	deployCmd.Flags().StringSliceVar(&skipResources, "skip-resources", []string{}, "Resources to leave as they are if the stack is UPDATE_ROLLBACK_FAILED and its rollback has to be continued. Use the format LogicalId1,LogicalId2, and NestedStack.LogicalId for resources in nested stacks.")
+	deployCmd.Flags().StringSliceVar(&deployRegions, "regions", []string{}, "Deploy the stack to each of these regions in parallel. Use the format us-east-1,eu-west-1.")
+	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Create and display the change set without deploying it. Exits with status 0 if there are no changes, 2 if there are changes, or 1 on error.")
 	Root.AddCommand(deployCmd)
 }
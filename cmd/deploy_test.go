@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+)
+
+func TestRequestCapabilities(t *testing.T) {
+	required := []capabilities.Capability{
+		{Name: capabilities.IAM, Reasons: []string{"Role"}},
+	}
+
+	actual := requestCapabilities(required, []string{capabilities.IAM, capabilities.AutoExpand})
+
+	expected := []capabilities.Capability{
+		{Name: capabilities.IAM, Reasons: []string{"Role", "requested by Metadata.Rain.Capabilities"}},
+		{Name: capabilities.AutoExpand, Reasons: []string{"requested by Metadata.Rain.Capabilities"}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %#v, want %#v", actual, expected)
+	}
+
+	if len(required[0].Reasons) != 1 {
+		t.Errorf("The required capabilities should not be changed: %#v", required)
+	}
+}
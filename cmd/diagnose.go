@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// operationStatuses are the stack statuses that mark the start of an operation
+var operationStatuses = map[string]bool{
+	"CREATE_IN_PROGRESS": true,
+	"UPDATE_IN_PROGRESS": true,
+	"DELETE_IN_PROGRESS": true,
+	"IMPORT_IN_PROGRESS": true,
+}
+
+func isStackEvent(event cloudformation.StackEvent) bool {
+	return event.PhysicalResourceId != nil && event.StackId != nil && *event.PhysicalResourceId == *event.StackId
+}
+
+// getOperationEvents returns the events for the stack's most recent operation in time order.
+// If since is not nil, all events after that time are returned instead.
+func getOperationEvents(region, stackName string, since *time.Time) ([]cloudformation.StackEvent, error) {
+	// Stop paging through the stack's history once the start of the operation has been found
+	events, err := cfn.In(region).GetStackEventsUntil(stackName, func(event cloudformation.StackEvent) bool {
+		return isOperationStart(event, since)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return operationEvents(events, since), nil
+}
+
+// isOperationStart returns true if event is the first event of the operation,
+// or if since is not nil, the first event before that time
+func isOperationStart(event cloudformation.StackEvent, since *time.Time) bool {
+	if since != nil {
+		return event.Timestamp.Before(*since)
+	}
+
+	return isStackEvent(event) && operationStatuses[string(event.ResourceStatus)]
+}
+
+// operationEvents returns the events, which are ordered newest first,
+// that belong to the operation in time order
+func operationEvents(events []cloudformation.StackEvent, since *time.Time) []cloudformation.StackEvent {
+	operation := make([]cloudformation.StackEvent, 0)
+	for _, event := range events {
+		if since != nil && event.Timestamp.Before(*since) {
+			break
+		}
+
+		operation = append([]cloudformation.StackEvent{event}, operation...)
+
+		if since == nil && isOperationStart(event, nil) {
+			break
+		}
+	}
+
+	return operation
+}
+
+// isRootCause returns true if the event is a failure that wasn't just caused by another failure
+func isRootCause(event cloudformation.StackEvent) bool {
+	if !strings.HasSuffix(string(event.ResourceStatus), "_FAILED") {
+		return false
+	}
+
+	if event.ResourceStatusReason == nil || isStackEvent(event) {
+		return false
+	}
+
+	return !strings.Contains(strings.ToLower(*event.ResourceStatusReason), "cancelled")
+}
+
+// findRootCause returns the first failure in the operation's events.
+// If the failure belongs to a nested stack, the failure within that stack
+// is also returned, and so on.
+func findRootCause(region, stackName string, since *time.Time) ([]cloudformation.StackEvent, error) {
+	events, err := getOperationEvents(region, stackName, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	start := *events[0].Timestamp
+
+	for _, event := range events {
+		if !isRootCause(event) {
+			continue
+		}
+
+		chain := []cloudformation.StackEvent{event}
+
+		if *event.ResourceType == "AWS::CloudFormation::Stack" && event.PhysicalResourceId != nil && *event.PhysicalResourceId != "" {
+			nested, err := findRootCause(region, *event.PhysicalResourceId, &start)
+			if err == nil {
+				chain = append(chain, nested...)
+			}
+		}
+
+		return chain, nil
+	}
+
+	return nil, nil
+}
+
+// diagnoseFailure explains why the most recent operation on a stack in region failed
+func diagnoseFailure(region, stackName string) string {
+	chain, err := findRootCause(region, stackName, nil)
+	if err != nil || len(chain) == 0 {
+		return fmt.Sprintf("Unable to find the cause of the failure. Run 'rain logs %s' for details.\n", stackName)
+	}
+
+	out := strings.Builder{}
+	out.WriteString("The deployment failed because:\n")
+
+	indent := "  "
+	for _, event := range chain {
+		out.WriteString(fmt.Sprintf("%s%s:  # %s\n", indent, text.Yellow(*event.LogicalResourceId), *event.ResourceType))
+		out.WriteString(fmt.Sprintf("%s  %s %s\n", indent, colouriseStatus(string(event.ResourceStatus)), text.White(fmt.Sprintf("%q", *event.ResourceStatusReason))))
+		indent += "  "
+	}
+
+	return out.String()
+}
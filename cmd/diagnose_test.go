@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func stackEvent(id string, status cloudformation.ResourceStatus, at time.Time) cloudformation.StackEvent {
+	physical := "stack-id"
+	if id != "Stack" {
+		physical = id + "-physical"
+	}
+
+	return cloudformation.StackEvent{
+		EventId:            aws.String(id + "-" + string(status)),
+		LogicalResourceId:  aws.String(id),
+		PhysicalResourceId: aws.String(physical),
+		StackId:            aws.String("stack-id"),
+		ResourceStatus:     status,
+		Timestamp:          aws.Time(at),
+	}
+}
+
+func TestOperationEvents(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Newest first, as returned by DescribeStackEvents
+	events := []cloudformation.StackEvent{
+		stackEvent("Stack", cloudformation.ResourceStatusUpdateComplete, start.Add(3*time.Minute)),
+		stackEvent("Bucket", cloudformation.ResourceStatusUpdateComplete, start.Add(2*time.Minute)),
+		stackEvent("Bucket", cloudformation.ResourceStatusUpdateInProgress, start.Add(time.Minute)),
+		stackEvent("Stack", cloudformation.ResourceStatusUpdateInProgress, start),
+		stackEvent("Stack", cloudformation.ResourceStatusCreateComplete, start.Add(-time.Hour)),
+	}
+
+	operation := operationEvents(events, nil)
+	if len(operation) != 4 || *operation[0].EventId != "Stack-UPDATE_IN_PROGRESS" || *operation[3].EventId != "Stack-UPDATE_COMPLETE" {
+		t.Errorf("Unexpected events: %v", operation)
+	}
+
+	since := start.Add(90 * time.Second)
+	operation = operationEvents(events, &since)
+	if len(operation) != 2 || *operation[0].EventId != "Bucket-UPDATE_COMPLETE" {
+		t.Errorf("Unexpected events since %s: %v", since, operation)
+	}
+
+	starts := 0
+	for _, event := range events {
+		if isOperationStart(event, nil) {
+			starts++
+			if *event.EventId != "Stack-UPDATE_IN_PROGRESS" {
+				t.Errorf("Unexpected start of operation: %s", *event.EventId)
+			}
+		}
+	}
+
+	if starts != 1 {
+		t.Errorf("Expected one start of operation, got %d", starts)
+	}
+
+	if isOperationStart(events[1], &since) || !isOperationStart(events[2], &since) {
+		t.Error("Events before since should mark the start of the operation")
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/spf13/cobra"
+)
+
+var driftResources []string
+var driftJSON = false
+
+// resourceDrift is the JSON representation of a drifted resource
+type resourceDrift struct {
+	LogicalResourceId  string
+	PhysicalResourceId string `json:",omitempty"`
+	ResourceType       string
+	DriftStatus        string
+	ExpectedProperties interface{} `json:",omitempty"`
+	ActualProperties   interface{} `json:",omitempty"`
+}
+
+// parseProperties converts the JSON properties in a drift result into a form that can be diffed
+func parseProperties(properties *string) interface{} {
+	if properties == nil {
+		return nil
+	}
+
+	var out interface{}
+	if err := json.Unmarshal([]byte(*properties), &out); err != nil {
+		return *properties
+	}
+
+	return out
+}
+
+// detectDrift runs drift detection on the stack and returns the drifted resources
+func detectDrift(stackName string) (cloudformation.DescribeStackDriftDetectionStatusOutput, []cloudformation.StackResourceDrift) {
+	spinner.Status(fmt.Sprintf("Detecting drift in stack '%s'...", stackName))
+	defer spinner.Stop()
+
+	detectionId, err := cfn.DetectStackDrift(stackName)
+	if err != nil {
+		panic(fmt.Errorf("Unable to detect drift in stack '%s': %s", stackName, err))
+	}
+
+	var status cloudformation.DescribeStackDriftDetectionStatusOutput
+
+	for {
+		status, err = cfn.GetStackDriftDetectionStatus(detectionId)
+		if err != nil {
+			panic(fmt.Errorf("Unable to detect drift in stack '%s': %s", stackName, err))
+		}
+
+		if status.DetectionStatus != cloudformation.StackDriftDetectionStatusDetectionInProgress {
+			break
+		}
+
+		time.Sleep(time.Second * 2)
+	}
+
+	drifts, err := cfn.GetStackResourceDrifts(stackName)
+	if err != nil {
+		panic(fmt.Errorf("Unable to get drift results for stack '%s': %s", stackName, err))
+	}
+
+	out := make([]cloudformation.StackResourceDrift, 0)
+	for _, drift := range drifts {
+		if len(driftResources) > 0 && !stringIn(*drift.LogicalResourceId, driftResources) {
+			continue
+		}
+
+		out = append(out, drift)
+	}
+
+	return status, out
+}
+
+// diffDrift compares the expected and actual properties of drifted resources
+func diffDrift(drifts []cloudformation.StackResourceDrift) diff.Diff {
+	expected := make(map[string]interface{})
+	actual := make(map[string]interface{})
+
+	for _, drift := range drifts {
+		name := *drift.LogicalResourceId
+
+		switch drift.StackResourceDriftStatus {
+		case cloudformation.StackResourceDriftStatusModified:
+			expected[name] = parseProperties(drift.ExpectedProperties)
+			actual[name] = parseProperties(drift.ActualProperties)
+		case cloudformation.StackResourceDriftStatusDeleted:
+			expected[name] = parseProperties(drift.ExpectedProperties)
+		}
+	}
+
+	return diff.New(expected, actual)
+}
+
+// formatDriftJSON describes the resources that have been modified or deleted as JSON
+func formatDriftJSON(stackName string, status cloudformation.DescribeStackDriftDetectionStatusOutput, drifts []cloudformation.StackResourceDrift) string {
+	resources := make([]resourceDrift, 0)
+	for _, drift := range drifts {
+		if drift.StackResourceDriftStatus != cloudformation.StackResourceDriftStatusModified && drift.StackResourceDriftStatus != cloudformation.StackResourceDriftStatusDeleted {
+			continue
+		}
+
+		resource := resourceDrift{
+			LogicalResourceId:  *drift.LogicalResourceId,
+			ResourceType:       *drift.ResourceType,
+			DriftStatus:        string(drift.StackResourceDriftStatus),
+			ExpectedProperties: parseProperties(drift.ExpectedProperties),
+			ActualProperties:   parseProperties(drift.ActualProperties),
+		}
+
+		if drift.PhysicalResourceId != nil {
+			resource.PhysicalResourceId = *drift.PhysicalResourceId
+		}
+
+		resources = append(resources, resource)
+	}
+
+	data, err := json.MarshalIndent(struct {
+		StackName   string
+		DriftStatus string
+		Resources   []resourceDrift
+	}{
+		StackName:   stackName,
+		DriftStatus: string(status.StackDriftStatus),
+		Resources:   resources,
+	}, "", "  ")
+	if err != nil {
+		panic(fmt.Errorf("Unable to format drift results: %s", err))
+	}
+
+	return string(data)
+}
+
+var driftCmd = &cobra.Command{
+	Use:                   "drift <stack>",
+	Short:                 "Show resources that have been changed outside of CloudFormation",
+	Long:                  "Runs drift detection on the CloudFormation stack named <stack> and shows how the properties of any resources that have drifted differ from their expected values.",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		stackName := args[0]
+
+		status, drifts := detectDrift(stackName)
+
+		if driftJSON {
+			fmt.Println(formatDriftJSON(stackName, status, drifts))
+			return
+		}
+
+		if status.DetectionStatus == cloudformation.StackDriftDetectionStatusDetectionFailed && status.DetectionStatusReason != nil {
+			fmt.Println(text.Orange(*status.DetectionStatusReason))
+		}
+
+		d := diffDrift(drifts)
+
+		notChecked := 0
+		for _, drift := range drifts {
+			if drift.StackResourceDriftStatus == cloudformation.StackResourceDriftStatusNotChecked {
+				notChecked++
+			}
+		}
+
+		if d.Mode() == diff.Unchanged {
+			fmt.Println(text.Green(fmt.Sprintf("No drift detected in stack '%s'", stackName)))
+		} else {
+			fmt.Printf("Stack '%s' has drifted:\n", stackName)
+			fmt.Print(colouriseDiff(d, false))
+		}
+
+		if notChecked > 0 {
+			fmt.Println(text.Grey(fmt.Sprintf("%d resources could not be checked for drift", notChecked)))
+		}
+
+		if len(driftResources) > 0 && len(drifts) == 0 {
+			panic(errors.New("None of the requested resources were found in the stack"))
+		}
+	},
+}
+
+func init() {
+	driftCmd.Flags().StringSliceVar(&driftResources, "resource", []string{}, "Only show drift for these resources. Use the format LogicalId1,LogicalId2.")
+	driftCmd.Flags().BoolVarP(&driftJSON, "json", "j", false, "Output the resources that have been modified or deleted as JSON.")
+	Root.AddCommand(driftCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestParseProperties(t *testing.T) {
+	if parseProperties(nil) != nil {
+		t.Error("Expected nil for missing properties")
+	}
+
+	actual := parseProperties(aws.String(`{"BucketName": "a", "Tags": [{"Key": "k", "Value": "v"}]}`))
+	expected := map[string]interface{}{
+		"BucketName": "a",
+		"Tags": []interface{}{
+			map[string]interface{}{"Key": "k", "Value": "v"},
+		},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	if parseProperties(aws.String("not json")) != "not json" {
+		t.Error("Invalid JSON should be returned as it is")
+	}
+}
+
+func testDrifts() []cloudformation.StackResourceDrift {
+	return []cloudformation.StackResourceDrift{
+		{
+			LogicalResourceId:        aws.String("Modified"),
+			ResourceType:             aws.String("AWS::S3::Bucket"),
+			StackResourceDriftStatus: cloudformation.StackResourceDriftStatusModified,
+			ExpectedProperties:       aws.String(`{"BucketName": "a"}`),
+			ActualProperties:         aws.String(`{"BucketName": "b"}`),
+		},
+		{
+			LogicalResourceId:        aws.String("Deleted"),
+			ResourceType:             aws.String("AWS::SQS::Queue"),
+			StackResourceDriftStatus: cloudformation.StackResourceDriftStatusDeleted,
+			ExpectedProperties:       aws.String(`{"QueueName": "q"}`),
+		},
+		{
+			LogicalResourceId:        aws.String("InSync"),
+			ResourceType:             aws.String("AWS::SNS::Topic"),
+			StackResourceDriftStatus: cloudformation.StackResourceDriftStatusInSync,
+			ExpectedProperties:       aws.String(`{"TopicName": "t"}`),
+			ActualProperties:         aws.String(`{"TopicName": "t"}`),
+		},
+		{
+			LogicalResourceId:        aws.String("NotChecked"),
+			ResourceType:             aws.String("AWS::Custom::Thing"),
+			StackResourceDriftStatus: cloudformation.StackResourceDriftStatusNotChecked,
+		},
+	}
+}
+
+func TestDiffDrift(t *testing.T) {
+	d := diffDrift(testDrifts())
+	if d.Mode() == diff.Unchanged {
+		t.Fatal("Expected drift")
+	}
+
+	resources := d.(diff.Map)
+
+	if resources["Modified"].Mode() == diff.Unchanged {
+		t.Error("Expected Modified to have changed")
+	}
+
+	if resources["Deleted"].Mode() != diff.Removed {
+		t.Errorf("Expected Deleted to have been removed, got %s", resources["Deleted"].Mode())
+	}
+
+	for _, name := range []string{"InSync", "NotChecked"} {
+		if _, ok := resources[name]; ok {
+			t.Errorf("%s should not be in the diff", name)
+		}
+	}
+
+	if diffDrift(testDrifts()[2:]).Mode() != diff.Unchanged {
+		t.Error("Expected no drift for resources that are in sync or not checked")
+	}
+}
+
+func TestFormatDriftJSON(t *testing.T) {
+	status := cloudformation.DescribeStackDriftDetectionStatusOutput{
+		StackDriftStatus: cloudformation.StackDriftStatusDrifted,
+	}
+
+	var out struct {
+		StackName   string
+		DriftStatus string
+		Resources   []resourceDrift
+	}
+
+	if err := json.Unmarshal([]byte(formatDriftJSON("stack", status, testDrifts())), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.StackName != "stack" || out.DriftStatus != "DRIFTED" {
+		t.Errorf("Unexpected stack: %s %s", out.StackName, out.DriftStatus)
+	}
+
+	if len(out.Resources) != 2 || out.Resources[0].LogicalResourceId != "Modified" || out.Resources[1].LogicalResourceId != "Deleted" {
+		t.Errorf("Expected only the modified and deleted resources, got %v", out.Resources)
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/iam"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/spf13/cobra"
+)
+
+func colouriseRisks(s string, risks []iam.Risk) string {
+	if len(risks) == 0 {
+		return s
+	}
+
+	parts := make([]string, len(risks))
+	colour := text.Orange
+	for i, risk := range risks {
+		parts[i] = string(risk)
+		if risk == iam.Admin {
+			colour = text.Red
+		}
+	}
+
+	return colour(fmt.Sprintf("%s  # %s", s, strings.Join(parts, ", "))).String()
+}
+
+func actionRisks(action string, statement iam.Statement) []iam.Risk {
+	risks := make([]iam.Risk, 0)
+
+	if statement.Effect != "Allow" {
+		return risks
+	}
+
+	if statement.IsAdmin() && (action == "*" || strings.HasPrefix(strings.ToLower(action), "iam:")) {
+		risks = append(risks, iam.Admin)
+	}
+
+	if iam.IsPassRole(action) {
+		risks = append(risks, iam.PassRole)
+	}
+
+	if iam.IsWildcard(action) {
+		risks = append(risks, iam.Wildcard)
+	}
+
+	return risks
+}
+
+func resourceRisks(resource string, statement iam.Statement) []iam.Risk {
+	if statement.Effect == "Allow" && iam.IsWildcard(resource) {
+		return []iam.Risk{iam.Wildcard}
+	}
+
+	return nil
+}
+
+func formatStatement(statement iam.Statement) string {
+	out := strings.Builder{}
+
+	out.WriteString(fmt.Sprintf("    - Policy: %s\n", colouriseRisks(statement.Policy, statement.Risks())))
+
+	fields := map[string][]string{
+		"Action":      statement.Action,
+		"NotAction":   statement.NotAction,
+		"Resource":    statement.Resource,
+		"NotResource": statement.NotResource,
+	}
+
+	keys := []string{"Effect"}
+	if statement.Sid != "" {
+		keys = append(keys, "Sid")
+	}
+	if statement.Condition {
+		keys = append(keys, "Condition")
+	}
+	for key, values := range fields {
+		if len(values) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	// Use the same ordering as rain fmt
+	for _, key := range format.SortKeys(keys, []interface{}{"PolicyDocument", "Statement", 0}) {
+		switch key {
+		case "Sid":
+			out.WriteString(fmt.Sprintf("      Sid: %s\n", statement.Sid))
+		case "Effect":
+			out.WriteString(fmt.Sprintf("      Effect: %s\n", statement.Effect))
+		case "Condition":
+			out.WriteString("      Condition: {...}\n")
+		default:
+			out.WriteString(fmt.Sprintf("      %s:\n", key))
+			for _, value := range fields[key] {
+				var risks []iam.Risk
+				if strings.HasSuffix(key, "Action") {
+					risks = actionRisks(value, statement)
+				} else {
+					risks = resourceRisks(value, statement)
+				}
+
+				out.WriteString(fmt.Sprintf("        - %s\n", colouriseRisks(value, risks)))
+			}
+		}
+	}
+
+	return out.String()
+}
+
+func formatPrincipal(p iam.Principal) string {
+	out := strings.Builder{}
+
+	if p.Type == "" {
+		out.WriteString(fmt.Sprintf("%s:  # %s\n", text.Yellow(p.Name), "not defined in this template"))
+	} else {
+		out.WriteString(fmt.Sprintf("%s:  # %s\n", text.Yellow(p.Name), p.Type))
+	}
+
+	if len(p.AssumedBy) > 0 {
+		out.WriteString("  AssumedBy:\n")
+		for _, principal := range p.AssumedBy {
+			var risks []iam.Risk
+			if principal == "*" {
+				risks = []iam.Risk{iam.Wildcard}
+			}
+			out.WriteString(fmt.Sprintf("    - %s\n", colouriseRisks(principal, risks)))
+		}
+	}
+
+	if len(p.ManagedPolicyArns) > 0 {
+		out.WriteString("  ManagedPolicyArns:\n")
+		for _, arn := range p.ManagedPolicyArns {
+			var risks []iam.Risk
+			if iam.IsAdminPolicy(arn) {
+				risks = []iam.Risk{iam.Admin}
+			}
+			out.WriteString(fmt.Sprintf("    - %s\n", colouriseRisks(arn, risks)))
+		}
+	}
+
+	if len(p.Statements) > 0 {
+		out.WriteString("  Statements:\n")
+		for _, statement := range p.Statements {
+			out.WriteString(formatStatement(statement))
+		}
+	}
+
+	return out.String()
+}
+
+var iamCmd = &cobra.Command{
+	Use:                   "iam <template>",
+	Short:                 "Summarise the IAM permissions granted by a template",
+	Long:                  "Lists every IAM role, user, and group in the CloudFormation template <template> along with the actions and resources that each is allowed.\n\nWildcards, iam:PassRole, and grants that are equivalent to admin access are highlighted.",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		fn := args[0]
+
+		t, err := parse.File(fn)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
+		}
+
+		principals := iam.Principals(t)
+
+		if len(principals) == 0 {
+			fmt.Println("No IAM principals found in " + fn)
+			return
+		}
+
+		for _, p := range principals {
+			fmt.Println(formatPrincipal(p))
+		}
+	},
+}
+
+func init() {
+	Root.AddCommand(iamCmd)
+}
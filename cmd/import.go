@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/spf13/cobra"
+)
+
+var importFlags []string
+
+// parseImportFlags returns the physical IDs given with --resource, keyed by logical ID
+func parseImportFlags(flags []string) map[string]string {
+	out := make(map[string]string)
+
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			panic(fmt.Errorf("Unable to parse resource '%s'. Use the format LogicalId=physical-id.", flag))
+		}
+
+		out[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	if len(out) == 0 {
+		panic(errors.New("No resources to import. Use --resource LogicalId=physical-id."))
+	}
+
+	return out
+}
+
+// checkImportable makes sure that each resource is in the template
+// and has a DeletionPolicy, which CloudFormation requires for imported resources
+func checkImportable(template cfnTemplate.Template, ids map[string]string) {
+	resources, _ := template["Resources"].(map[string]interface{})
+
+	missing := make([]string, 0)
+
+	for id := range ids {
+		resource, ok := resources[id].(map[string]interface{})
+		if !ok {
+			panic(fmt.Errorf("Resource '%s' is not in the template", id))
+		}
+
+		if _, ok := resource["DeletionPolicy"]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		panic(fmt.Errorf("Resources must have a DeletionPolicy to be imported: %s", strings.Join(missing, ", ")))
+	}
+}
+
+// makeResourcesToImport matches each physical ID with the properties that CloudFormation uses to identify the resource.
+// Resources that are identified by more than one property must be given as Key1=Value1,Key2=Value2.
+func makeResourcesToImport(ids map[string]string, identifiers map[string]cfn.ResourceIdentifier) []cfn.ResourceToImport {
+	names := make([]string, 0, len(ids))
+	for id := range ids {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+
+	out := make([]cfn.ResourceToImport, len(names))
+
+	for i, id := range names {
+		identifier, ok := identifiers[id]
+		if !ok {
+			panic(fmt.Errorf("Resource '%s' cannot be imported", id))
+		}
+
+		value := ids[id]
+		values := make(map[string]string)
+
+		if len(identifier.Keys) == 1 && !strings.HasPrefix(value, identifier.Keys[0]+"=") {
+			values[identifier.Keys[0]] = value
+		} else {
+			for _, pair := range strings.Split(value, ",") {
+				parts := strings.SplitN(pair, "=", 2)
+				if len(parts) != 2 {
+					panic(fmt.Errorf("Resource '%s' is identified by %s. Use the format %s=%s=value,...", id, strings.Join(identifier.Keys, ", "), id, identifier.Keys[0]))
+				}
+
+				values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+
+		for _, key := range identifier.Keys {
+			if values[key] == "" {
+				panic(fmt.Errorf("Resource '%s' is missing its %s", id, key))
+			}
+		}
+
+		out[i] = cfn.ResourceToImport{
+			LogicalResourceId: id,
+			ResourceType:      identifier.ResourceType,
+			Identifier:        values,
+		}
+	}
+
+	return out
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <template> <stack>",
+	Short: "Import existing resources into a CloudFormation stack",
+	Long: `Adopts resources that were created outside of CloudFormation into the stack named <stack>, creating the stack if it does not exist.
+
+<template> must contain the stack's existing resources as well as the ones to import, and each imported resource must have a DeletionPolicy.
+Use --resource to give the physical ID of each resource to import.`,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		fn := args[0]
+		stackName := args[1]
+
+		d := &deployment{stackName: stackName}
+		handleInterrupts(true)
+		defer d.handleInterrupt()
+
+		ids := parseImportFlags(importFlags)
+
+		source, err := parse.File(fn)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
+		}
+
+		paramValues, fileTags := getParameterValues(source)
+
+		parsedTags := parseTags(tags)
+		for key, value := range fileTags {
+			if _, ok := parsedTags[key]; !ok {
+				parsedTags[key] = value
+			}
+		}
+
+		checkImportable(source, ids)
+
+		fmt.Printf("Importing resources from '%s' into '%s' in %s:\n", filepath.Base(fn), stackName, client.Config().Region)
+
+		fmt.Print("Preparing template... ")
+
+		bucket := getRainBucket("")
+
+		parsedTemplate, template := packageTemplate(fn, source, bucket, "")
+
+		config.Debugf("Packaged template:\n%s", template)
+
+		console.ClearLine()
+		fmt.Printf("Checking current status of stack '%s'... ", stackName)
+
+		stack, err := cfn.GetStack(stackName)
+		d.stackExists = err == nil
+
+		if d.stackExists && !strings.HasSuffix(string(stack.StackStatus), "_COMPLETE") {
+			panic(fmt.Errorf("Stack '%s' could not be updated: %s", stackName, colouriseStatus(string(stack.StackStatus))))
+		}
+
+		console.ClearLine()
+
+		parameters := getParameters(parsedTemplate, paramValues, stack.Parameters, false, !force)
+
+		requiredCapabilities := capabilities.RequiredIn(source, filepath.Dir(fn))
+		if len(requiredCapabilities) > 0 {
+			fmt.Println("This template requires the following capabilities:")
+			fmt.Print(formatCapabilities(requiredCapabilities))
+
+			if !force && !console.Confirm(true, "Do you wish to grant these capabilities?") {
+				panic(errors.New("User cancelled import."))
+			}
+		}
+
+		spinner.Status("Creating change set...")
+
+		identifiers, err := cfn.GetResourceIdentifiers(template, bucket)
+		if err != nil {
+			panic(fmt.Errorf("Unable to find how to identify the resources in '%s': %s", fn, err))
+		}
+
+		resources := makeResourcesToImport(ids, identifiers)
+
+		changeSetName, err := cfn.CreateImportChangeSet(template, parameters, parsedTags, stackName, capabilities.Names(requiredCapabilities), bucket, stackOptions(), resources)
+		d.changeSetName = changeSetName
+		if err != nil {
+			if changeSet, csErr := cfn.DescribeChangeSet(stackName, changeSetName); csErr == nil && changeSet.StatusReason != nil {
+				panic(fmt.Errorf("Error while creating changeset for '%s': %s", stackName, *changeSet.StatusReason))
+			}
+
+			panic(fmt.Errorf("Error while creating changeset for '%s': %s", stackName, err))
+		}
+
+		changes, err := cfn.GetChangeSet(stackName, changeSetName)
+		if err != nil {
+			panic(fmt.Errorf("Error while retrieving changeset '%s': %s", changeSetName, err))
+		}
+		spinner.Stop()
+
+		fmt.Println("CloudFormation will make the following changes:")
+		fmt.Println(formatChangeSet(changes))
+
+		if !force && !console.Confirm(true, "Do you wish to continue?") {
+			cancelChangeSet("", stackName, changeSetName, d.stackExists)
+			panic(errors.New("User cancelled import."))
+		}
+
+		d.executing = true
+		executeChangeSet(stackName, changeSetName)
+	},
+}
+
+func init() {
+	importCmd.Flags().StringArrayVar(&importFlags, "resource", []string{}, "A resource to import. Use the format LogicalId=physical-id, or LogicalId=Key1=Value1,Key2=Value2 for resources that are identified by more than one property. May be repeated.")
+	importCmd.Flags().BoolVarP(&force, "force", "f", false, "Don't ask questions; just import.")
+	importCmd.Flags().StringSliceVar(&tags, "tags", []string{}, "Add tags to the stack. Use the format key1=value1,key2=value2.")
+	importCmd.Flags().StringVar(&paramsFile, "params", "", "Read parameter values from a file. The file may be a CloudFormation parameters file, a CodePipeline template configuration file, or a YAML map of parameter names to values.")
+	importCmd.Flags().StringArrayVar(&paramFlags, "param", []string{}, "Set a parameter value. Use the format key=value. May be repeated.")
+	importCmd.Flags().StringVar(&roleArn, "role-arn", "", "The ARN of an IAM role that CloudFormation uses to import the resources.")
+	Root.AddCommand(importCmd)
+}
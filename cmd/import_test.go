@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/client/cfn"
+)
+
+func TestParseImportFlags(t *testing.T) {
+	actual := parseImportFlags([]string{"Bucket=my-bucket", " Route = RouteTableId=rtb-1234,DestinationCidrBlock=0.0.0.0/0"})
+
+	expected := map[string]string{
+		"Bucket": "my-bucket",
+		"Route":  "RouteTableId=rtb-1234,DestinationCidrBlock=0.0.0.0/0",
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	for _, flags := range [][]string{{"Bucket"}, {"=my-bucket"}, {"Bucket="}, {}} {
+		if err := catch(func() { parseImportFlags(flags) }); err == nil {
+			t.Errorf("Expected an error for %q", flags)
+		}
+	}
+}
+
+func TestMakeResourcesToImport(t *testing.T) {
+	identifiers := map[string]cfn.ResourceIdentifier{
+		"Bucket": {ResourceType: "AWS::S3::Bucket", Keys: []string{"BucketName"}},
+		"Route":  {ResourceType: "AWS::EC2::Route", Keys: []string{"RouteTableId", "DestinationCidrBlock"}},
+	}
+
+	actual := makeResourcesToImport(map[string]string{
+		"Route":  "RouteTableId=rtb-1234, DestinationCidrBlock=0.0.0.0/0",
+		"Bucket": "my-bucket",
+	}, identifiers)
+
+	expected := []cfn.ResourceToImport{
+		{
+			LogicalResourceId: "Bucket",
+			ResourceType:      "AWS::S3::Bucket",
+			Identifier:        map[string]string{"BucketName": "my-bucket"},
+		},
+		{
+			LogicalResourceId: "Route",
+			ResourceType:      "AWS::EC2::Route",
+			Identifier:        map[string]string{"RouteTableId": "rtb-1234", "DestinationCidrBlock": "0.0.0.0/0"},
+		},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	// A single key may also be given by name
+	actual = makeResourcesToImport(map[string]string{"Bucket": "BucketName=my-bucket"}, identifiers)
+	if actual[0].Identifier["BucketName"] != "my-bucket" {
+		t.Errorf("Unexpected identifier: %v", actual[0].Identifier)
+	}
+
+	for _, ids := range []map[string]string{
+		{"Route": "rtb-1234"},
+		{"Route": "RouteTableId=rtb-1234"},
+		{"Queue": "my-queue"},
+	} {
+		if err := catch(func() { makeResourcesToImport(ids, identifiers) }); err == nil {
+			t.Errorf("Expected an error for %v", ids)
+		}
+	}
+}
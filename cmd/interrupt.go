@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// Exit code used when rain is stopped by Ctrl-C
+const exitInterrupted = 130
+
+// handlingInterrupts is set while a command is able to clean up after Ctrl-C
+var handlingInterrupts int32
+
+// listenForInterrupts stops rain when the user presses Ctrl-C.
+// If a command is handling interrupts, the first Ctrl-C cancels
+// any AWS calls in progress so that the command can clean up instead.
+func listenForInterrupts() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		for range signals {
+			if atomic.LoadInt32(&handlingInterrupts) == 1 && !client.Interrupted() {
+				client.Interrupt()
+				continue
+			}
+
+			spinner.Stop()
+			fmt.Println()
+			os.Exit(exitInterrupted)
+		}
+	}()
+}
+
+func handleInterrupts(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&handlingInterrupts, 1)
+	} else {
+		atomic.StoreInt32(&handlingInterrupts, 0)
+	}
+}
+
+// deployment records how far a deployment has got
+// so that it can be cleaned up if it is interrupted
+type deployment struct {
+	region        string
+	stackName     string
+	changeSetName string
+	stackExists   bool
+	executing     bool
+}
+
+// handleInterrupt must be deferred by the command running the deployment.
+// If the deployment was interrupted, it offers to clean up the change set
+// or cancel the update, depending on how far the deployment had got.
+func (d *deployment) handleInterrupt() {
+	handleInterrupts(false)
+
+	if r := recover(); r != nil {
+		cleanUpInterrupted(r, []*deployment{d})
+	}
+}
+
+// handleInterruptAll is handleInterrupt for commands that run several deployments at once
+func handleInterruptAll(deployments []*deployment) {
+	handleInterrupts(false)
+
+	if r := recover(); r != nil {
+		cleanUpInterrupted(r, deployments)
+	}
+}
+
+// cleanUpInterrupted re-raises r unless it was caused by Ctrl-C,
+// in which case it cleans up each of the deployments
+func cleanUpInterrupted(r interface{}, deployments []*deployment) {
+	if r != console.ErrInterrupted && !client.Interrupted() {
+		panic(r)
+	}
+
+	client.ResetContext()
+	spinner.Stop()
+	fmt.Println()
+
+	for _, d := range deployments {
+		if d.executing {
+			d.interruptExecution()
+		} else if d.changeSetName != "" {
+			d.interruptChangeSet()
+		}
+	}
+
+	ExitCode = exitInterrupted
+	panic(errors.New("Deployment interrupted"))
+}
+
+// describe names the deployment's stack, and its region if it has one
+func (d *deployment) describe() string {
+	if d.region == "" {
+		return fmt.Sprintf("'%s'", d.stackName)
+	}
+
+	return fmt.Sprintf("'%s' in %s", d.stackName, d.region)
+}
+
+// interruptChangeSet offers to delete a change set that has not been executed
+func (d *deployment) interruptChangeSet() {
+	if !force && console.IsTTY && !console.Confirm(true, fmt.Sprintf("Do you wish to delete change set '%s'?", d.changeSetName)) {
+		fmt.Printf("Change set '%s' has not been deleted.\n", d.changeSetName)
+		return
+	}
+
+	spinner.Status(fmt.Sprintf("Deleting change set '%s'...", d.changeSetName))
+	err := catch(func() {
+		cancelChangeSet(d.region, d.stackName, d.changeSetName, d.stackExists)
+	})
+	spinner.Stop()
+
+	if err != nil {
+		fmt.Println(text.Red(err.Error()))
+	} else {
+		fmt.Printf("Deleted change set '%s'.\n", d.changeSetName)
+	}
+}
+
+// interruptExecution offers to cancel an update that is in progress.
+// Otherwise, the stack is left to carry on without rain watching it.
+func (d *deployment) interruptExecution() {
+	stack, err := cfn.In(d.region).GetStack(d.stackName)
+	if err != nil {
+		fmt.Println(text.Red(fmt.Sprintf("Unable to get the status of stack %s: %s", d.describe(), err)))
+		return
+	}
+
+	// The change set may not have been executed yet
+	if stack.StackStatus == cloudformation.StackStatusReviewInProgress || stackHasSettled(stack) {
+		d.interruptChangeSet()
+		return
+	}
+
+	if stack.StackStatus == cloudformation.StackStatusUpdateInProgress &&
+		!force && console.IsTTY &&
+		console.Confirm(false, fmt.Sprintf("Do you wish to cancel the update to stack %s? If not, it will carry on without rain watching it", d.describe())) {
+
+		err := cfn.In(d.region).CancelUpdateStack(d.stackName)
+		if err != nil {
+			fmt.Println(text.Red(fmt.Sprintf("Unable to cancel the update to stack %s: %s", d.describe(), err)))
+			return
+		}
+
+		var status string
+		if d.region == "" {
+			status = waitForStackToSettle(d.stackName)
+		} else {
+			spinner.Status(fmt.Sprintf("Cancelling the update to stack %s...", d.describe()))
+			status = waitForStack(d.region, d.stackName)
+			spinner.Stop()
+		}
+		fmt.Printf("Cancelled the update to stack %s: %s\n", d.describe(), colouriseStatus(status))
+		return
+	}
+
+	fmt.Printf("Stack %s is %s. Run 'rain watch %s' to resume watching it.\n", d.describe(), colouriseStatus(string(stack.StackStatus)), d.stackName)
+}
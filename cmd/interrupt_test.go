@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/aws-cloudformation/rain/console"
+)
+
+func TestCleanUpInterruptedExitCode(t *testing.T) {
+	defer func() {
+		ExitCode = exitNoChanges
+	}()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected the interruption to be re-raised")
+		}
+
+		if ErrorExitCode() != exitInterrupted {
+			t.Errorf("Expected exit code %d, got %d", exitInterrupted, ErrorExitCode())
+		}
+	}()
+
+	if ErrorExitCode() != 1 {
+		t.Errorf("Expected errors to exit with 1, got %d", ErrorExitCode())
+	}
+
+	cleanUpInterrupted(console.ErrInterrupted, nil)
+}
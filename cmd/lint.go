@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/lint"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/spf13/cobra"
+)
+
+var lintWarnings = false
+var fixLint = false
+var rulesDir = ".rain/rules"
+
+func colouriseLevel(level lint.Level) text.Text {
+	if level == lint.Error {
+		return text.Red(level.String())
+	}
+
+	return text.Orange(level.String())
+}
+
+func formatProblems(problems []lint.Problem) string {
+	out := strings.Builder{}
+
+	lastPath := ""
+	for _, problem := range problems {
+		path := lint.FormatPath(problem.Path)
+		if path != lastPath {
+			out.WriteString(fmt.Sprintf("%s:\n", text.Yellow(path)))
+			lastPath = path
+		}
+
+		out.WriteString(fmt.Sprintf("  - %s: %s  # %s\n", colouriseLevel(problem.Level), problem.Message, problem.Rule))
+	}
+
+	return out.String()
+}
+
+// lintRules returns the built-in rules along with any policies found in rulesDir
+func lintRules() []lint.Rule {
+	policies, err := lint.LoadPolicies(rulesDir)
+	if err != nil {
+		panic(fmt.Errorf("Unable to load rules: %s", err))
+	}
+
+	config.Debugf("Loaded %d rules from %s", len(policies), rulesDir)
+
+	return append(append([]lint.Rule{}, lint.Rules...), policies...)
+}
+
+// lintTemplate checks the template and prints any problems found.
+// It returns false if any of the problems should prevent deployment.
+func lintTemplate(t cfn.Template) bool {
+	return printProblems(lint.Check(t, lintRules()...))
+}
+
+// shownProblems returns the problems that should be shown, omitting warnings unless --all was given
+func shownProblems(problems []lint.Problem) []lint.Problem {
+	if lintWarnings {
+		return problems
+	}
+
+	shown := make([]lint.Problem, 0)
+	for _, problem := range problems {
+		if problem.Level == lint.Error {
+			shown = append(shown, problem)
+		}
+	}
+
+	return shown
+}
+
+// printProblems prints problems, omitting warnings unless --all was given.
+// It returns false if there are any errors.
+func printProblems(problems []lint.Problem) bool {
+	problems = shownProblems(problems)
+
+	if len(problems) == 0 {
+		return true
+	}
+
+	fmt.Print(formatProblems(problems))
+	fmt.Println()
+
+	return !lint.HasErrors(problems)
+}
+
+// fixTemplate applies the available fixes for the problems that would be shown to the template read from fn,
+// shows the resulting changes, and writes the template back to fn once the user has confirmed them
+func fixTemplate(fn string, input []byte, t cfn.Template, problems []lint.Problem) {
+	if lint.Fix(t, shownProblems(problems)) == 0 {
+		fmt.Println("No problems can be fixed automatically")
+		return
+	}
+
+	// Parse a fresh copy to compare against
+	original, err := parse.String(string(input))
+	if err != nil {
+		panic(fmt.Errorf("Unable to parse '%s': %s", fn, err))
+	}
+
+	d := original.Diff(t)
+	if d.Mode() == diff.Unchanged {
+		fmt.Println("No problems can be fixed automatically")
+		return
+	}
+
+	options := format.Options{}
+	if strings.HasPrefix(strings.TrimSpace(string(input)), "{") {
+		options.Style = format.JSON
+	}
+
+	output := format.Template(t, options)
+
+	// Make sure the fixed template survives formatting before it replaces the original
+	err = parse.Verify(t, output)
+	if err != nil {
+		panic(fmt.Errorf("Unable to fix '%s': %s", fn, err))
+	}
+
+	fmt.Print(colouriseDiff(d, false))
+	fmt.Println()
+
+	if !force && !console.Confirm(true, fmt.Sprintf("Do you wish to write these changes to '%s'?", fn)) {
+		panic(errors.New("User cancelled fixes."))
+	}
+
+	err = ioutil.WriteFile(fn, []byte(output), 0644)
+	if err != nil {
+		panic(fmt.Errorf("Unable to write '%s': %s", fn, err))
+	}
+
+	fmt.Println(text.Green("Fixed problems in " + fn))
+	fmt.Println()
+}
+
+var lintCmd = &cobra.Command{
+	Use:                   "lint <template>",
+	Short:                 "Check a CloudFormation template for problems",
+	Long:                  "Checks the CloudFormation template <template> for problems that CloudFormation would not report, such as secrets stored in plain text.\n\nYou can add your own rules by writing YAML files in the .rain/rules directory. Each file contains a list of Rules, each with a Name, a Resource type, a Path to a property, and one or more of Exists, Equals, Matches, and In.\n\nWith --fix, rain will fix any of the problems it reports that have a mechanical fix, show the changes, and write the template back to the file once you have confirmed them. As warnings are only reported with --all, use --fix --all to fix warnings as well as errors.",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		fn := args[0]
+
+		input, err := ioutil.ReadFile(fn)
+		if err != nil {
+			panic(fmt.Errorf("Unable to read '%s': %s", fn, err))
+		}
+
+		t, err := parse.String(string(input))
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
+		}
+
+		rules := lintRules()
+		problems := lint.Check(t, rules...)
+
+		if fixLint {
+			fixTemplate(fn, input, t, problems)
+			problems = lint.Check(t, rules...)
+		}
+
+		if !printProblems(problems) {
+			panic(fmt.Errorf("Template '%s' has errors", fn))
+		}
+
+		fmt.Println(text.Green("No errors found in " + fn))
+	},
+}
+
+func init() {
+	lintCmd.Flags().BoolVarP(&lintWarnings, "all", "a", false, "Include warnings as well as errors")
+	lintCmd.Flags().BoolVar(&fixLint, "fix", false, "Fix any reported problems that have a mechanical fix and write the template back to the file.")
+	lintCmd.Flags().BoolVarP(&force, "force", "f", false, "With --fix, don't ask before writing the fixes.")
+	lintCmd.Flags().StringVar(&rulesDir, "rules", rulesDir, "Directory to load additional rules from.")
+	Root.AddCommand(lintCmd)
+}
@@ -15,6 +15,56 @@ var timeOrder = false
 var longFormat = false
 var allLogs = false
 
+// getNestedStackEvents returns the events for a stack and any stacks nested within it,
+// newest first. The logical IDs of nested resources are prefixed with their stack's logical ID.
+func getNestedStackEvents(stackName string, prefix string) ([]cloudformation.StackEvent, error) {
+	events, err := cfn.GetStackEvents(stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]cloudformation.StackEvent, 0, len(events))
+	nested := make(map[string]string)
+	nestedOrder := make([]string, 0)
+
+	for _, event := range events {
+		// The parent stack already has an event for each of a nested stack's own events
+		if prefix != "" && isStackEvent(event) {
+			continue
+		}
+
+		if *event.ResourceType == "AWS::CloudFormation::Stack" && !isStackEvent(event) && event.PhysicalResourceId != nil && *event.PhysicalResourceId != "" {
+			if _, ok := nested[*event.PhysicalResourceId]; !ok {
+				nested[*event.PhysicalResourceId] = *event.LogicalResourceId
+				nestedOrder = append(nestedOrder, *event.PhysicalResourceId)
+			}
+		}
+
+		if prefix != "" {
+			name := prefix + *event.LogicalResourceId
+			event.LogicalResourceId = &name
+		}
+
+		out = append(out, event)
+	}
+
+	for _, stackId := range nestedOrder {
+		childEvents, err := getNestedStackEvents(stackId, prefix+nested[stackId]+"/")
+		if err != nil {
+			// The nested stack may not exist any more
+			continue
+		}
+
+		out = append(out, childEvents...)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.After(*out[j].Timestamp)
+	})
+
+	return out, nil
+}
+
 var uninterestingMessages = map[string]bool{
 	"Resource creation Initiated": true,
 	"User Initiated":              true,
@@ -54,7 +104,7 @@ func printLogs(logs []cloudformation.StackEvent) {
 var logsCmd = &cobra.Command{
 	Use:                   "logs <stack> (<resource>)",
 	Short:                 "Show the event log for the named stack",
-	Long:                  "Shows a nicely-formatted list of the event log for the named stack, optionally limiting the results to a single resource.\n\nBy default, rain will only show log entries that contain a message, for example a failure reason. You can use flags to change this behaviour.",
+	Long:                  "Shows a nicely-formatted list of the event log for the named stack, optionally limiting the results to a single resource. Events from nested stacks are included, with their resources named <stack>/<resource>.\n\nBy default, rain will only show log entries that contain a message, for example a failure reason. You can use flags to change this behaviour.",
 	Args:                  cobra.RangeArgs(1, 2),
 	Aliases:               []string{"log"},
 	DisableFlagsInUseLine: true,
@@ -63,7 +113,7 @@ var logsCmd = &cobra.Command{
 
 		// Get logs
 		spinner.Status(fmt.Sprintf("Getting logs for %s...", stackName))
-		logs, err := cfn.GetStackEvents(stackName)
+		logs, err := getNestedStackEvents(stackName, "")
 		if err != nil {
 			panic(fmt.Errorf("Failed to get events for '%s': %s", stackName, err))
 		}
@@ -48,8 +48,7 @@ var lsCmd = &cobra.Command{
 			for _, region := range regions {
 				spinner.Status(fmt.Sprintf("Fetching stacks in %s...", region))
 
-				client.SetRegion(region)
-				stacks, err := cfn.ListStacks()
+				stacks, err := cfn.In(region).ListStacks()
 				if err != nil {
 					panic(fmt.Errorf("Failed to list stacks: %s", err))
 				}
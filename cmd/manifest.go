@@ -0,0 +1,651 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws-cloudformation/rain/cfn/manifest"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+var manifestFile = ""
+
+// target is the AWS profile and region that a stack is deployed with
+type target struct {
+	profile string
+	region  string
+}
+
+// stackResult is the outcome of deploying or deleting one stack in a manifest
+type stackResult struct {
+	name      string
+	region    string
+	status    string
+	duration  time.Duration
+	outputs   map[string]string
+	diagnosis string
+	err       error
+}
+
+const (
+	statusNoChanges = "NO_CHANGES"
+	statusNotFound  = "NOT_FOUND"
+	statusSkipped   = "SKIPPED"
+)
+
+// catch runs fn and returns any panic as an error
+func catch(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+func loadManifest(fn string) (manifest.Manifest, map[string]cfnTemplate.Template, [][]string) {
+	m, err := manifest.Load(fn)
+	if err != nil {
+		panic(err)
+	}
+
+	templates := make(map[string]cfnTemplate.Template)
+	for _, name := range m.Names() {
+		path := m.TemplatePath(m.Stacks[name])
+
+		t, err := parse.File(path)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s' for stack '%s': %s", path, name, err))
+		}
+
+		templates[name] = t
+	}
+
+	waves, err := m.Order(templates)
+	if err != nil {
+		panic(err)
+	}
+
+	return m, templates, waves
+}
+
+func formatWaves(waves [][]string) string {
+	out := strings.Builder{}
+
+	for i, wave := range waves {
+		out.WriteString(fmt.Sprintf("  %d. %s\n", i+1, text.Yellow(strings.Join(wave, ", "))))
+	}
+
+	return out.String()
+}
+
+// useProfile switches the AWS configuration to the given profile
+func useProfile(profile string) {
+	config.Profile = profile
+	client.Reset()
+	client.Config()
+}
+
+// runWaves runs fn for each stack in waves, one wave at a time,
+// passing the region that the stack should be deployed to.
+// Stacks within a wave that share a profile are run in parallel, whatever their region.
+// If any stack fails, the remaining waves are skipped.
+func runWaves(m manifest.Manifest, waves [][]string, fn func(stack *manifest.Stack, region string) stackResult) []stackResult {
+	defaultProfile := config.Profile
+	defer useProfile(defaultProfile)
+
+	results := make([]stackResult, 0)
+	failed := false
+
+	for _, wave := range waves {
+		if failed {
+			for _, name := range wave {
+				results = append(results, stackResult{name: name, region: m.Stacks[name].Region, status: statusSkipped})
+			}
+			continue
+		}
+
+		// Group the wave's stacks by profile
+		groups := make(map[string][]string)
+		profiles := make([]string, 0)
+		for _, name := range wave {
+			profile := defaultProfile
+			if m.Stacks[name].Profile != "" {
+				profile = m.Stacks[name].Profile
+			}
+
+			if _, ok := groups[profile]; !ok {
+				profiles = append(profiles, profile)
+			}
+			groups[profile] = append(groups[profile], name)
+		}
+
+		for _, profile := range profiles {
+			names := groups[profile]
+			groupResults := make([]stackResult, len(names))
+
+			err := catch(func() {
+				useProfile(profile)
+			})
+			if err != nil {
+				for i, name := range names {
+					groupResults[i] = stackResult{name: name, region: m.Stacks[name].Region, err: err}
+				}
+			} else {
+				var wg sync.WaitGroup
+				for i, name := range names {
+					wg.Add(1)
+					go func(i int, stack *manifest.Stack) {
+						defer wg.Done()
+
+						start := time.Now()
+						groupResults[i] = fn(stack, stack.Region)
+						groupResults[i].name = stack.Name
+						groupResults[i].region = client.ConfigFor(stack.Region).Region
+						groupResults[i].duration = time.Since(start).Round(time.Second)
+					}(i, m.Stacks[name])
+				}
+				wg.Wait()
+			}
+
+			for _, result := range groupResults {
+				if result.err != nil {
+					failed = true
+				}
+				results = append(results, result)
+			}
+		}
+	}
+
+	return results
+}
+
+func formatResults(results []stackResult) string {
+	rows := [][]string{{"Stack", "Region", "Result", "Time"}}
+	for _, r := range results {
+		duration := ""
+		if r.duration > 0 {
+			duration = r.duration.String()
+		}
+
+		status := r.status
+		if r.err != nil && status == "" {
+			status = "FAILED"
+		}
+
+		rows = append(rows, []string{r.name, r.region, status, duration})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	out := strings.Builder{}
+	for i, row := range rows {
+		for j, cell := range row {
+			padded := cell + strings.Repeat(" ", widths[j]-len(cell))
+
+			switch {
+			case i == 0:
+				out.WriteString(text.Bold(padded).String())
+			case j == 0:
+				out.WriteString(text.Yellow(padded).String())
+			case j == 2 && results[i-1].err != nil:
+				out.WriteString(text.Red(padded).String())
+			case j == 2 && (cell == statusNoChanges || cell == statusNotFound):
+				out.WriteString(text.Green(padded).String())
+			case j == 2 && cell == statusSkipped:
+				out.WriteString(text.Grey(padded).String())
+			case j == 2:
+				out.WriteString(colouriseStatus(padded).String())
+			default:
+				out.WriteString(padded)
+			}
+
+			if j < len(row)-1 {
+				out.WriteString("  ")
+			}
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// reportResults prints the summary table and any failures,
+// and panics if any stack failed
+func reportResults(results []stackResult, action string) {
+	fmt.Println()
+	fmt.Print(formatResults(results))
+
+	failures := make([]string, 0)
+	for _, r := range results {
+		if r.err == nil {
+			continue
+		}
+
+		failures = append(failures, r.name)
+
+		fmt.Println()
+		fmt.Printf("%s: %s\n", text.Yellow(r.name), text.Red(r.err.Error()))
+		fmt.Print(r.diagnosis)
+	}
+
+	fmt.Println()
+
+	if len(failures) > 0 {
+		panic(fmt.Errorf("Failed to %s: %s", action, strings.Join(failures, ", ")))
+	}
+}
+
+// waitForStack waits for a stack in region to settle without drawing its progress
+func waitForStack(region, stackName string) string {
+	stackId := stackName
+
+	for {
+		stack, err := cfn.In(region).GetStack(stackId)
+		if err != nil {
+			panic(fmt.Errorf("Operation failed: %s", err))
+		}
+
+		stackId = *stack.StackId
+
+		if stackHasSettled(stack) {
+			return string(stack.StackStatus)
+		}
+
+		time.Sleep(time.Second * 5)
+	}
+}
+
+func getStackOutputs(region, stackName string) map[string]string {
+	stack, err := cfn.In(region).GetStack(stackName)
+	if err != nil {
+		panic(fmt.Errorf("Unable to get outputs of stack '%s': %s", stackName, err))
+	}
+
+	outputs := make(map[string]string)
+	for _, output := range stack.Outputs {
+		outputs[*output.OutputKey] = *output.OutputValue
+	}
+
+	return outputs
+}
+
+// manifestChange is a change set that has been created for a stack in a manifest but not yet executed
+type manifestChange struct {
+	changeSetName string
+	stackExists   bool
+	capabilities  []capabilities.Capability
+	changes       []cloudformation.Change
+
+	// recreate is set if the stack is ROLLBACK_COMPLETE and must be deleted
+	// before its change set can be created, so the change set is created once the user has confirmed
+	recreate   bool
+	body       string
+	bucket     string
+	parameters []cloudformation.Parameter
+	tags       map[string]string
+}
+
+// createManifestChangeSet packages a stack's template and creates a change set for it in region
+func createManifestChangeSet(m manifest.Manifest, stack *manifest.Stack, template cfnTemplate.Template, bucket, region string, values map[string]string) *manifestChange {
+	api := cfn.In(region)
+	fn := m.TemplatePath(stack)
+
+	packaged, body := packageTemplate(fn, template, bucket, region)
+
+	mc := &manifestChange{
+		body:         body,
+		bucket:       bucket,
+		capabilities: capabilities.RequiredIn(template, filepath.Dir(fn)),
+		tags:         make(map[string]string),
+	}
+
+	existing, err := api.GetStack(stack.Name)
+	mc.stackExists = err == nil
+
+	if mc.stackExists {
+		status := string(existing.StackStatus)
+
+		if status == "ROLLBACK_COMPLETE" {
+			mc.recreate = true
+		} else if !strings.HasSuffix(status, "_COMPLETE") {
+			panic(fmt.Errorf("Stack could not be updated: %s", status))
+		}
+	}
+
+	mc.parameters = getParameters(packaged, values, existing.Parameters, mc.recreate, false)
+
+	for key, value := range stack.Tags {
+		mc.tags[key] = value
+	}
+
+	if provenance {
+		added := addProvenanceTags(filepath.Dir(fn), mc.tags)
+
+		if mc.stackExists && !mc.recreate {
+			keepProvenanceTags(region, existing, packaged, mc.parameters, mc.tags, added)
+		}
+	}
+
+	if mc.recreate {
+		return mc
+	}
+
+	mc.changeSetName, err = api.CreateChangeSet(body, mc.parameters, mc.tags, stack.Name, capabilities.Names(mc.capabilities), bucket, stackOptions())
+	if err != nil && changeSetIsEmpty(region, stack.Name, mc.changeSetName) {
+		cancelChangeSet(region, stack.Name, mc.changeSetName, mc.stackExists)
+		mc.changeSetName = ""
+		return mc
+	} else if err != nil {
+		panic(fmt.Errorf("Error while creating changeset: %s", err))
+	}
+
+	mc.changes, err = api.GetChangeSet(stack.Name, mc.changeSetName)
+	if err != nil {
+		panic(fmt.Errorf("Error while retrieving changeset '%s': %s", mc.changeSetName, err))
+	}
+
+	return mc
+}
+
+// hasChanges returns true if executing the change would change the stack
+func (mc *manifestChange) hasChanges() bool {
+	return mc.recreate || mc.changeSetName != ""
+}
+
+// formatManifestChanges shows the capabilities and changes for each stack in a wave
+func formatManifestChanges(wave []string, changes map[string]*manifestChange) string {
+	out := strings.Builder{}
+
+	for _, name := range wave {
+		mc := changes[name]
+
+		out.WriteString(fmt.Sprintf("%s:\n", text.Yellow(name)))
+
+		if len(mc.capabilities) > 0 {
+			out.WriteString("  This template requires the following capabilities:\n")
+			out.WriteString(indent(formatCapabilities(mc.capabilities), "  "))
+		}
+
+		switch {
+		case mc.recreate:
+			out.WriteString(text.Orange("  The stack is ROLLBACK_COMPLETE and will be deleted and created again\n").String())
+		case !mc.hasChanges():
+			out.WriteString(text.Green("  No changes to deploy!\n").String())
+		default:
+			out.WriteString(indent(formatChangeSet(mc.changes), "  "))
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// executeManifestChange executes a stack's change set in region and waits for the stack to settle
+func executeManifestChange(stack *manifest.Stack, region string, mc *manifestChange) (result stackResult) {
+	result.err = catch(func() {
+		api := cfn.In(region)
+
+		if !mc.hasChanges() {
+			result.status = statusNoChanges
+			result.outputs = getStackOutputs(region, stack.Name)
+			return
+		}
+
+		fmt.Printf("%s: deploying...\n", text.Yellow(stack.Name))
+
+		if mc.recreate {
+			err := api.DeleteStack(stack.Name, roleArn)
+			if err != nil {
+				panic(fmt.Errorf("Unable to delete stack: %s", err))
+			}
+
+			if waitForStack(region, stack.Name) != "DELETE_COMPLETE" {
+				panic(errors.New("Unable to delete stack from its ROLLBACK_COMPLETE state"))
+			}
+
+			mc.changeSetName, err = api.CreateChangeSet(mc.body, mc.parameters, mc.tags, stack.Name, capabilities.Names(mc.capabilities), mc.bucket, stackOptions())
+			if err != nil {
+				panic(fmt.Errorf("Error while creating changeset: %s", err))
+			}
+		}
+
+		err := api.ExecuteChangeSet(stack.Name, mc.changeSetName, disableRollback)
+		if err != nil {
+			panic(fmt.Errorf("Error while executing changeset: %s", err))
+		}
+
+		result.status = waitForStack(region, stack.Name)
+		if result.status != "CREATE_COMPLETE" && result.status != "UPDATE_COMPLETE" {
+			result.diagnosis = diagnoseFailure(region, stack.Name)
+			panic(errors.New("Failed deployment"))
+		}
+
+		updateStackSettings(region, stack.Name)
+
+		fmt.Printf("%s: %s\n", text.Yellow(stack.Name), colouriseStatus(result.status))
+
+		result.outputs = getStackOutputs(region, stack.Name)
+	})
+
+	return result
+}
+
+// cancelManifestChanges deletes the change sets that were created for a wave
+func cancelManifestChanges(m manifest.Manifest, wave []string, changes map[string]*manifestChange) {
+	runWaves(m, [][]string{wave}, func(stack *manifest.Stack, region string) (result stackResult) {
+		mc, ok := changes[stack.Name]
+		if !ok || mc.changeSetName == "" {
+			return result
+		}
+
+		result.err = catch(func() {
+			cancelChangeSet(region, stack.Name, mc.changeSetName, mc.stackExists)
+		})
+		if result.err != nil {
+			fmt.Printf("%s: %s\n", text.Yellow(stack.Name), text.Red(result.err.Error()))
+		}
+
+		return result
+	})
+}
+
+// deployManifest deploys the stacks in a manifest one wave at a time.
+// The change sets for each wave are created first, then the required capabilities and changes are shown
+// so that the user can confirm them before any are executed.
+func deployManifest(fn string) {
+	m, templates, waves := loadManifest(fn)
+
+	if lintDeploy {
+		for _, name := range m.Names() {
+			if !lintTemplate(templates[name]) {
+				panic(fmt.Errorf("Template '%s' for stack '%s' has errors; not deploying", m.TemplatePath(m.Stacks[name]), name))
+			}
+		}
+	}
+
+	fmt.Printf("Deploying %d stacks from '%s' in this order:\n", len(m.Stacks), fn)
+	fmt.Print(formatWaves(waves))
+	fmt.Println()
+
+	var mu sync.Mutex
+	outputs := make(map[string]map[string]string)
+	buckets := make(map[target]string)
+
+	results := make([]stackResult, 0)
+	failed := false
+
+	for i, wave := range waves {
+		if failed {
+			for _, name := range wave {
+				results = append(results, stackResult{name: name, region: m.Stacks[name].Region, status: statusSkipped})
+			}
+			continue
+		}
+
+		// Create the wave's change sets
+		changes := make(map[string]*manifestChange)
+
+		created := runWaves(m, [][]string{wave}, func(stack *manifest.Stack, region string) (result stackResult) {
+			// Artifact buckets belong to an account as well as a region
+			t := target{config.Profile, client.ConfigFor(region).Region}
+
+			mu.Lock()
+			values, err := stack.ResolveParameters(outputs)
+			if err == nil {
+				err = catch(func() {
+					if _, ok := buckets[t]; !ok {
+						buckets[t] = getRainBucket(region)
+					}
+				})
+			}
+			bucket := buckets[t]
+			mu.Unlock()
+
+			if err != nil {
+				result.err = err
+				return result
+			}
+
+			result.err = catch(func() {
+				mc := createManifestChangeSet(m, stack, templates[stack.Name], bucket, region, values)
+
+				mu.Lock()
+				changes[stack.Name] = mc
+				mu.Unlock()
+			})
+
+			return result
+		})
+
+		for _, result := range created {
+			if result.err != nil {
+				failed = true
+			}
+		}
+
+		if failed {
+			cancelManifestChanges(m, wave, changes)
+			results = append(results, created...)
+			continue
+		}
+
+		// Show the changes and ask for confirmation
+		fmt.Printf("Wave %d of %d:\n", i+1, len(waves))
+		fmt.Print(formatManifestChanges(wave, changes))
+
+		changed := 0
+		for _, name := range wave {
+			if changes[name].hasChanges() {
+				changed++
+			}
+		}
+
+		if changed > 0 && !force && !console.Confirm(true, fmt.Sprintf("Do you wish to deploy %d stacks and grant their capabilities?", changed)) {
+			cancelManifestChanges(m, wave, changes)
+			panic(errors.New("User cancelled deployment."))
+		}
+
+		// Execute the change sets
+		executed := runWaves(m, [][]string{wave}, func(stack *manifest.Stack, region string) stackResult {
+			return executeManifestChange(stack, region, changes[stack.Name])
+		})
+
+		for _, result := range executed {
+			if result.err != nil {
+				failed = true
+			}
+
+			outputs[result.name] = result.outputs
+		}
+
+		results = append(results, executed...)
+	}
+
+	reportResults(results, "deploy")
+}
+
+func rmManifest(fn string) {
+	m, _, waves := loadManifest(fn)
+
+	// Delete dependents first
+	for i, j := 0, len(waves)-1; i < j; i, j = i+1, j-1 {
+		waves[i], waves[j] = waves[j], waves[i]
+	}
+
+	fmt.Printf("Deleting %d stacks from '%s' in this order:\n", len(m.Stacks), fn)
+	fmt.Print(formatWaves(waves))
+
+	if !forceRm && !console.Confirm(false, "Are you sure you want to delete these stacks?") {
+		panic(errors.New("User cancelled deletion."))
+	}
+
+	results := runWaves(m, waves, func(stack *manifest.Stack, region string) (result stackResult) {
+		result.err = catch(func() {
+			api := cfn.In(region)
+
+			exists, err := api.StackExists(stack.Name)
+			if err != nil {
+				panic(err)
+			}
+
+			if !exists {
+				result.status = statusNotFound
+				return
+			}
+
+			existing, err := api.GetStack(stack.Name)
+			if err != nil {
+				panic(err)
+			}
+
+			checkTerminationProtection(region, existing)
+
+			fmt.Printf("%s: deleting...\n", text.Yellow(stack.Name))
+
+			err = api.DeleteStack(stack.Name, roleArn)
+			if err != nil {
+				panic(fmt.Errorf("Unable to delete stack: %s", err))
+			}
+
+			result.status = waitForStack(region, stack.Name)
+			if result.status != "DELETE_COMPLETE" {
+				result.diagnosis = diagnoseFailure(region, stack.Name)
+				panic(errors.New("Failed to delete stack"))
+			}
+
+			fmt.Printf("%s: %s\n", text.Yellow(stack.Name), colouriseStatus(result.status))
+		})
+
+		return result
+	})
+
+	reportResults(results, "delete")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestFormatManifestChanges(t *testing.T) {
+	changes := map[string]*manifestChange{
+		"network": {},
+		"app": {
+			changeSetName: "cs",
+			capabilities: []capabilities.Capability{
+				{Name: capabilities.IAM, Reasons: []string{"Role (AWS::IAM::Role)"}},
+			},
+			changes: []cloudformation.Change{
+				{ResourceChange: &cloudformation.ResourceChange{
+					Action:            cloudformation.ChangeActionAdd,
+					ResourceType:      aws.String("AWS::IAM::Role"),
+					LogicalResourceId: aws.String("Role"),
+				}},
+			},
+		},
+		"broken": {recreate: true},
+	}
+
+	out := formatManifestChanges([]string{"network", "app", "broken"}, changes)
+
+	for _, expected := range []string{
+		"network:\n  No changes to deploy!\n",
+		"app:\n  This template requires the following capabilities:\n    CAPABILITY_IAM:\n      - Role (AWS::IAM::Role)\n",
+		"  (+) AWS::IAM::Role Role",
+		"broken:\n  The stack is ROLLBACK_COMPLETE and will be deleted and created again\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q:\n%s", expected, out)
+		}
+	}
+
+	if changes["network"].hasChanges() || !changes["app"].hasChanges() || !changes["broken"].hasChanges() {
+		t.Error("Unexpected hasChanges")
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/params"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// getParameterValues returns the parameter values and tags supplied by --params and --param.
+// Values in the parameters file for parameters that the template doesn't declare are skipped with a warning,
+// so that one file can be shared between templates.
+func getParameterValues(template cfn.Template) (map[string]string, map[string]string) {
+	values := make(map[string]string)
+	fileTags := make(map[string]string)
+
+	if paramsFile != "" {
+		data, err := ioutil.ReadFile(paramsFile)
+		if err != nil {
+			panic(fmt.Errorf("Unable to read parameters file '%s': %s", paramsFile, err))
+		}
+
+		f, err := params.Parse(data)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse parameters file '%s': %s", paramsFile, err))
+		}
+
+		declared := make(map[string]bool)
+		for _, name := range params.Names(template) {
+			declared[name] = true
+		}
+
+		for key, value := range f.Parameters {
+			if !declared[key] {
+				fmt.Println(text.Orange(fmt.Sprintf("Skipping parameter '%s' from '%s' as it is not defined in the template", key, paramsFile)))
+				continue
+			}
+
+			values[key] = value
+		}
+
+		fileTags = f.Tags
+	}
+
+	for _, param := range paramFlags {
+		parts := strings.SplitN(param, "=", 2)
+
+		if len(parts) != 2 {
+			panic(fmt.Errorf("Unable to parse parameter: %s", param))
+		}
+
+		values[strings.TrimSpace(parts[0])] = parts[1]
+	}
+
+	return values, fileTags
+}
+
+// getParameters returns the parameters to deploy the template with.
+// If prompt is true, the user is asked for any values that were not supplied.
+func getParameters(template cfn.Template, values map[string]string, old []cloudformation.Parameter, forceOldValue bool, prompt bool) []cloudformation.Parameter {
+	newParams := make([]cloudformation.Parameter, 0)
+
+	names := params.Names(template)
+
+	// Check that we've been given values for parameters that exist
+	for key := range values {
+		if err := params.Validate(template, key, values[key]); err != nil {
+			panic(err)
+		}
+	}
+
+	oldMap := make(map[string]cloudformation.Parameter)
+	for _, param := range old {
+		oldMap[*param.ParameterKey] = param
+	}
+
+	for _, name := range names {
+		// New variable so we don't mess up the pointers below
+		key := name
+
+		if value, ok := values[key]; ok {
+			newParams = append(newParams, cloudformation.Parameter{
+				ParameterKey:   &key,
+				ParameterValue: &value,
+			})
+			continue
+		}
+
+		extra := ""
+		oldParam, hasExisting := oldMap[key]
+		defaultValue, hasDefault := params.Default(template, key)
+
+		if hasExisting {
+			extra = fmt.Sprintf(" (existing value: %s)", fmt.Sprint(*oldParam.ParameterValue))
+		} else if hasDefault {
+			extra = fmt.Sprintf(" (default value: %s)", defaultValue)
+		}
+
+		newValue := ""
+
+		if !prompt {
+			if !hasExisting && !hasDefault {
+				panic(fmt.Errorf("Parameter '%s' requires a value. Set a default or supply one with --params or --param.", key))
+			}
+		} else {
+			for {
+				newValue = console.Ask(fmt.Sprintf("Enter a value for parameter '%s'%s:", key, extra))
+
+				if newValue == "" && !hasExisting && !hasDefault {
+					fmt.Println(text.Red(fmt.Sprintf("Parameter '%s' requires a value", key)))
+					continue
+				}
+
+				if newValue != "" {
+					if err := params.Validate(template, key, newValue); err != nil {
+						fmt.Println(text.Red(err.Error()))
+						continue
+					}
+				}
+
+				break
+			}
+		}
+
+		if newValue != "" {
+			newParams = append(newParams, cloudformation.Parameter{
+				ParameterKey:   &key,
+				ParameterValue: &newValue,
+			})
+		} else if hasExisting && forceOldValue {
+			newParams = append(newParams, cloudformation.Parameter{
+				ParameterKey:   &key,
+				ParameterValue: oldParam.ParameterValue,
+			})
+		} else if hasExisting {
+			newParams = append(newParams, cloudformation.Parameter{
+				ParameterKey:     &key,
+				UsePreviousValue: &hasExisting,
+			})
+		}
+	}
+
+	return newParams
+}
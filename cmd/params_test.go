@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/parse"
+)
+
+func TestGetParametersWithoutPrompting(t *testing.T) {
+	template, err := parse.String(`
+Parameters:
+  Name:
+    Type: String
+  Size:
+    Type: Number
+    Default: 1
+Resources: {}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := getParameters(template, map[string]string{"Name": "x"}, nil, false, false)
+	if len(params) != 1 || *params[0].ParameterKey != "Name" || *params[0].ParameterValue != "x" {
+		t.Errorf("Unexpected parameters: %v", params)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected a panic for a missing parameter value")
+		}
+
+		if err, ok := r.(error); !ok || !strings.Contains(err.Error(), "Parameter 'Name' requires a value") {
+			t.Errorf("Unexpected panic: %v", r)
+		}
+	}()
+
+	getParameters(template, map[string]string{}, nil, false, false)
+}
+
+func TestGetParameterValuesSkipsUnknownFileParameters(t *testing.T) {
+	template, err := parse.String(`
+Parameters:
+  Name:
+    Type: String
+Resources: {}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString("Name: x\nSize: 3\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	defer func() { paramsFile = "" }()
+	paramsFile = f.Name()
+
+	values, _ := getParameterValues(template)
+	if !reflect.DeepEqual(values, map[string]string{"Name": "x"}) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
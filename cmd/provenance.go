@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/client/sts"
+	"github.com/aws-cloudformation/rain/console/run"
+	"github.com/aws-cloudformation/rain/version"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+var provenance = false
+var provenanceTagFlags map[string]string
+
+// defaultProvenanceTags maps each kind of provenance information to the tag it is stored in
+var defaultProvenanceTags = map[string]string{
+	"commit":   "rain:git-commit",
+	"branch":   "rain:git-branch",
+	"dirty":    "rain:git-dirty",
+	"repo":     "rain:git-repo",
+	"identity": "rain:deployed-by",
+	"version":  "rain:version",
+}
+
+// provenanceTagNames returns the tag names to use, with any overrides from --provenance-tags.
+// Kinds that have been given an empty name are left out.
+func provenanceTagNames() map[string]string {
+	names := make(map[string]string)
+	for kind, name := range defaultProvenanceTags {
+		names[kind] = name
+	}
+
+	for kind, name := range provenanceTagFlags {
+		if _, ok := defaultProvenanceTags[kind]; !ok {
+			kinds := make([]string, 0, len(defaultProvenanceTags))
+			for k := range defaultProvenanceTags {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+
+			panic(fmt.Errorf("Unknown provenance tag '%s'. Use one of: %s", kind, strings.Join(kinds, ", ")))
+		}
+
+		if name == "" {
+			delete(names, kind)
+		} else {
+			names[kind] = name
+		}
+	}
+
+	return names
+}
+
+// git runs a git command in dir and returns its output,
+// or an empty string if dir is not in a git repository
+func git(dir string, args ...string) string {
+	out, err := run.Capture("git", append([]string{"-C", dir}, args...)...)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(out)
+}
+
+// cleanRepoURL removes any credentials from a remote's URL
+func cleanRepoURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil {
+		return remote
+	}
+
+	u.User = nil
+
+	return u.String()
+}
+
+// getProvenanceTags describes where a deployment came from:
+// the git commit of the template in dir, who is deploying it, and with which version of rain
+func getProvenanceTags(dir string) map[string]string {
+	values := map[string]string{
+		"version": version.VERSION,
+	}
+
+	if commit := git(dir, "rev-parse", "HEAD"); commit != "" {
+		values["commit"] = commit
+		values["branch"] = git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+		values["repo"] = cleanRepoURL(git(dir, "config", "--get", "remote.origin.url"))
+
+		if git(dir, "status", "--porcelain") != "" {
+			values["dirty"] = "true"
+		} else {
+			values["dirty"] = "false"
+		}
+	}
+
+	if id, err := sts.GetCallerId(); err == nil {
+		values["identity"] = *id.Arn
+	}
+
+	out := make(map[string]string)
+	for kind, name := range provenanceTagNames() {
+		if values[kind] != "" {
+			out[name] = values[kind]
+		}
+	}
+
+	return out
+}
+
+// addProvenanceTags adds the provenance tags for dir to tags, leaving any tags that the user has set alone,
+// and returns the names of the tags that were added
+func addProvenanceTags(dir string, tags map[string]string) map[string]bool {
+	added := make(map[string]bool)
+
+	for key, value := range getProvenanceTags(dir) {
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+			added[key] = true
+		}
+	}
+
+	return added
+}
+
+// keepProvenanceTags replaces the provenance tags with the stack's existing values
+// if nothing else about the deployment has changed,
+// so that redeploying the same stack from another commit or by another user doesn't update it.
+// Tags that the user has set, i.e. those that are in tags but not in added, are treated like any other tag.
+func keepProvenanceTags(region string, stack cloudformation.Stack, template cfnTemplate.Template, parameters []cloudformation.Parameter, tags map[string]string, added map[string]bool) {
+	restoreProvenanceTags(stack, template, parameters, tags, added, func() string {
+		oldTemplate, err := cfn.In(region).GetStackTemplate(*stack.StackName, false)
+		if err != nil {
+			return ""
+		}
+
+		return oldTemplate
+	})
+}
+
+// restoreProvenanceTags does the work of keepProvenanceTags.
+// getTemplate returns the stack's current template, or an empty string if it can't be retrieved,
+// and is only called once the tags and parameters are known to be unchanged.
+func restoreProvenanceTags(stack cloudformation.Stack, template cfnTemplate.Template, parameters []cloudformation.Parameter, tags map[string]string, added map[string]bool, getTemplate func() string) {
+	// Include the default names so that leaving a tag out with --provenance-tags isn't seen as a change
+	names := make(map[string]bool)
+	for _, nameMap := range []map[string]string{defaultProvenanceTags, provenanceTagNames()} {
+		for _, name := range nameMap {
+			if _, set := tags[name]; !set || added[name] {
+				names[name] = true
+			}
+		}
+	}
+
+	// Compare the other tags
+	oldTags := make(map[string]string)
+	for _, tag := range stack.Tags {
+		oldTags[*tag.Key] = *tag.Value
+	}
+
+	for key, value := range tags {
+		if old, ok := oldTags[key]; !names[key] && (!ok || old != value) {
+			return
+		}
+	}
+
+	for key := range oldTags {
+		if _, ok := tags[key]; !names[key] && !ok {
+			return
+		}
+	}
+
+	// Compare the parameters
+	oldParams := make(map[string]string)
+	for _, param := range stack.Parameters {
+		oldParams[*param.ParameterKey] = *param.ParameterValue
+	}
+
+	if len(parameters) != len(oldParams) {
+		return
+	}
+
+	for _, param := range parameters {
+		if param.UsePreviousValue != nil && *param.UsePreviousValue {
+			continue
+		}
+
+		if old, ok := oldParams[*param.ParameterKey]; !ok || param.ParameterValue == nil || old != *param.ParameterValue {
+			return
+		}
+	}
+
+	// Compare the templates
+	oldTemplateString := getTemplate()
+	if oldTemplateString == "" {
+		return
+	}
+
+	oldTemplate, err := parse.String(oldTemplateString)
+	if err != nil || oldTemplate.Diff(template).Mode() != diff.Unchanged {
+		return
+	}
+
+	for name := range names {
+		if old, ok := oldTags[name]; ok {
+			tags[name] = old
+		} else {
+			delete(tags, name)
+		}
+	}
+}
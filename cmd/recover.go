@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+var skipResources []string
+
+// recoveryAction describes what deploy must do before it can update a stack with the given status,
+// or returns an empty string if the stack can be updated as it is
+func recoveryAction(status string) string {
+	switch {
+	case status == "ROLLBACK_COMPLETE":
+		return "delete and recreate it"
+	case status == "REVIEW_IN_PROGRESS":
+		return "delete the empty stack and its pending change sets"
+	case status == "UPDATE_ROLLBACK_FAILED":
+		return "continue rolling back the last update"
+	case strings.HasSuffix(status, "_IN_PROGRESS"):
+		return "wait for the current operation to finish"
+	}
+
+	return ""
+}
+
+// recoveryStep is what recoverStack must do next with a stack
+type recoveryStep int
+
+const (
+	stepNone recoveryStep = iota
+	stepDeleteReview
+	stepContinueRollback
+	stepWait
+)
+
+// nextRecoveryStep returns what recoverStack must do with a stack with the given status
+func nextRecoveryStep(status string) recoveryStep {
+	switch {
+	case status == "REVIEW_IN_PROGRESS":
+		return stepDeleteReview
+	case status == "UPDATE_ROLLBACK_FAILED":
+		return stepContinueRollback
+	case strings.HasSuffix(status, "_IN_PROGRESS"):
+		return stepWait
+	}
+
+	return stepNone
+}
+
+// recoveryOperations are the calls that recoverStack makes to get a stack out of each state
+type recoveryOperations struct {
+	deleteReview     func(stackName string)
+	continueRollback func(stack cloudformation.Stack)
+	wait             func(stackName, status string) string
+	getStack         func(stackName string) cloudformation.Stack
+}
+
+var defaultRecoveryOperations = recoveryOperations{
+	deleteReview:     deleteReviewStack,
+	continueRollback: continueRollback,
+	wait: func(stackName, status string) string {
+		if !force && !console.Confirm(true, fmt.Sprintf("Stack '%s' is %s. Do you wish to wait for it to finish?", stackName, colouriseStatus(status))) {
+			panic(fmt.Errorf("Stack '%s' could not be updated: %s", stackName, colouriseStatus(status)))
+		}
+
+		return waitForStackToSettle(stackName)
+	},
+	getStack: func(stackName string) cloudformation.Stack {
+		stack, err := cfn.GetStack(stackName)
+		if err != nil {
+			panic(fmt.Errorf("Unable to get the status of stack '%s': %s", stackName, err))
+		}
+
+		return stack
+	},
+}
+
+// recoverStack gets the stack out of any state that deploy can be guided out of:
+// it waits for operations that are in progress, deletes stacks that are still waiting for their first change set,
+// and continues rollbacks that have failed.
+// It returns the stack's new state and whether the stack still exists.
+// If the stack no longer exists, the returned state is empty.
+func recoverStack(stack cloudformation.Stack) (cloudformation.Stack, bool) {
+	return defaultRecoveryOperations.recover(stack)
+}
+
+func (ops recoveryOperations) recover(stack cloudformation.Stack) (cloudformation.Stack, bool) {
+	stackName := *stack.StackName
+
+	for {
+		status := string(stack.StackStatus)
+
+		switch nextRecoveryStep(status) {
+		case stepDeleteReview:
+			ops.deleteReview(stackName)
+			return cloudformation.Stack{}, false
+		case stepContinueRollback:
+			ops.continueRollback(stack)
+		case stepWait:
+			// A stack that has finished deleting can be created again
+			if ops.wait(stackName, status) == "DELETE_COMPLETE" {
+				return cloudformation.Stack{}, false
+			}
+		default:
+			return stack, true
+		}
+
+		stack = ops.getStack(stackName)
+	}
+}
+
+// formatReviewStack describes a stack that was never created and its pending change sets
+func formatReviewStack(stackName string, changeSets []cloudformation.ChangeSetSummary) string {
+	out := strings.Builder{}
+
+	out.WriteString(fmt.Sprintf("Stack '%s' has not been created yet and has %d pending change sets:\n", stackName, len(changeSets)))
+	for _, changeSet := range changeSets {
+		out.WriteString(fmt.Sprintf("  %s: %s\n", *changeSet.ChangeSetName, colouriseStatus(string(changeSet.Status))))
+	}
+
+	return out.String()
+}
+
+// deleteReviewStack deletes a stack that was never created, along with its pending change sets
+func deleteReviewStack(stackName string) {
+	changeSets, err := cfn.ListChangeSets(stackName)
+	if err != nil {
+		panic(fmt.Errorf("Unable to list change sets for stack '%s': %s", stackName, err))
+	}
+
+	fmt.Print(formatReviewStack(stackName, changeSets))
+
+	if !force && !console.Confirm(true, "Do you wish to delete the empty stack and its change sets?") {
+		panic(fmt.Errorf("Stack '%s' could not be updated: %s", stackName, colouriseStatus("REVIEW_IN_PROGRESS")))
+	}
+
+	err = cfn.DeleteStack(stackName, roleArn)
+	if err != nil {
+		panic(fmt.Errorf("Unable to delete stack '%s': %s", stackName, err))
+	}
+
+	if status := waitForStackToSettle(stackName); status != "DELETE_COMPLETE" {
+		panic(fmt.Errorf("Failed to delete " + stackName))
+	}
+}
+
+// failedResource is a resource that failed to roll back
+type failedResource struct {
+	// id names the resource as ContinueUpdateRollback's ResourcesToSkip expects:
+	// NestedStack.Resource for resources in nested stacks, where NestedStack is the nested stack's logical ID
+	id     string
+	reason string
+}
+
+// failedResources returns the resources that failed to roll back, looking inside nested stacks.
+// prefix is the logical ID of the nested stack that resources belong to, or empty for the root stack.
+func failedResources(resources []cloudformation.StackResource, cache stackResources, prefix string) []failedResource {
+	out := make([]failedResource, 0)
+
+	for _, resource := range resources {
+		if resource.ResourceStatus != cloudformation.ResourceStatusUpdateFailed {
+			continue
+		}
+
+		// A nested stack can't be skipped while it exists, but the resources inside it can
+		if isNestedStack(resource) {
+			out = append(out, failedResources(cache.get(*resource.PhysicalResourceId), cache, *resource.LogicalResourceId)...)
+			continue
+		}
+
+		failed := failedResource{id: *resource.LogicalResourceId}
+		if prefix != "" {
+			failed.id = prefix + "." + failed.id
+		}
+
+		if resource.ResourceStatusReason != nil {
+			failed.reason = *resource.ResourceStatusReason
+		}
+
+		out = append(out, failed)
+	}
+
+	return out
+}
+
+// continueRollback continues rolling back a stack that is UPDATE_ROLLBACK_FAILED,
+// offering to skip the resources that could not be rolled back
+func continueRollback(stack cloudformation.Stack) {
+	stackName := *stack.StackName
+
+	fmt.Printf("Stack '%s' is %s.\n", stackName, colouriseStatus(string(stack.StackStatus)))
+	if stack.StackStatusReason != nil {
+		fmt.Println(text.Yellow(*stack.StackStatusReason))
+	}
+
+	skip := skipResources
+
+	if !force {
+		if !console.Confirm(true, "Do you wish to continue rolling back the last update?") {
+			panic(fmt.Errorf("Stack '%s' could not be updated: %s", stackName, colouriseStatus(string(stack.StackStatus))))
+		}
+
+		if len(skip) == 0 {
+			cache := stackResources{}
+
+			for _, resource := range failedResources(cache.get(stackName), cache, "") {
+				reason := ""
+				if resource.reason != "" {
+					reason = " (" + resource.reason + ")"
+				}
+
+				if console.Confirm(false, fmt.Sprintf("Resource '%s' failed to roll back%s. Do you wish to skip it?", resource.id, reason)) {
+					skip = append(skip, resource.id)
+				}
+			}
+		}
+	}
+
+	err := cfn.ContinueUpdateRollback(stackName, roleArn, skip)
+	if err != nil {
+		panic(fmt.Errorf("Unable to continue rolling back stack '%s': %s", stackName, err))
+	}
+
+	if status := waitForStackToSettle(stackName); status != "UPDATE_ROLLBACK_COMPLETE" {
+		fmt.Print(diagnoseFailure("", stackName))
+		panic(errors.New("Failed to roll back " + stackName))
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestRecoveryAction(t *testing.T) {
+	cases := map[string]string{
+		"CREATE_COMPLETE":                     "",
+		"UPDATE_COMPLETE":                     "",
+		"UPDATE_ROLLBACK_COMPLETE":            "",
+		"ROLLBACK_COMPLETE":                   "delete and recreate it",
+		"REVIEW_IN_PROGRESS":                  "delete the empty stack and its pending change sets",
+		"UPDATE_ROLLBACK_FAILED":              "continue rolling back the last update",
+		"UPDATE_IN_PROGRESS":                  "wait for the current operation to finish",
+		"DELETE_IN_PROGRESS":                  "wait for the current operation to finish",
+		"UPDATE_COMPLETE_CLEANUP_IN_PROGRESS": "wait for the current operation to finish",
+		"CREATE_FAILED":                       "",
+	}
+
+	for status, expected := range cases {
+		if actual := recoveryAction(status); actual != expected {
+			t.Errorf("%s: expected %q, got %q", status, expected, actual)
+		}
+	}
+}
+
+func TestNextRecoveryStep(t *testing.T) {
+	cases := map[string]recoveryStep{
+		"UPDATE_COMPLETE":        stepNone,
+		"ROLLBACK_COMPLETE":      stepNone,
+		"REVIEW_IN_PROGRESS":     stepDeleteReview,
+		"UPDATE_ROLLBACK_FAILED": stepContinueRollback,
+		"UPDATE_IN_PROGRESS":     stepWait,
+		"DELETE_IN_PROGRESS":     stepWait,
+	}
+
+	for status, expected := range cases {
+		if actual := nextRecoveryStep(status); actual != expected {
+			t.Errorf("%s: expected %d, got %d", status, expected, actual)
+		}
+	}
+}
+
+// testRecovery returns recovery operations that record what they are asked to do.
+// Each call to getStack returns the next of statuses.
+func testRecovery(calls *[]string, waited string, statuses ...cloudformation.StackStatus) recoveryOperations {
+	return recoveryOperations{
+		deleteReview: func(stackName string) {
+			*calls = append(*calls, "deleteReview")
+		},
+		continueRollback: func(stack cloudformation.Stack) {
+			*calls = append(*calls, "continueRollback")
+		},
+		wait: func(stackName, status string) string {
+			*calls = append(*calls, "wait "+status)
+			return waited
+		},
+		getStack: func(stackName string) cloudformation.Stack {
+			*calls = append(*calls, "getStack")
+			status := statuses[0]
+			statuses = statuses[1:]
+			return cloudformation.Stack{StackName: aws.String(stackName), StackStatus: status}
+		},
+	}
+}
+
+func TestRecoverStack(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   cloudformation.StackStatus
+		waited   string
+		statuses []cloudformation.StackStatus
+		exists   bool
+		calls    []string
+	}{
+		{
+			name:   "settled",
+			status: cloudformation.StackStatusUpdateComplete,
+			exists: true,
+		},
+		{
+			name:   "review",
+			status: cloudformation.StackStatusReviewInProgress,
+			calls:  []string{"deleteReview"},
+		},
+		{
+			name:   "deleted while waiting",
+			status: cloudformation.StackStatusDeleteInProgress,
+			waited: "DELETE_COMPLETE",
+			calls:  []string{"wait DELETE_IN_PROGRESS"},
+		},
+		{
+			name:     "rollback failed after waiting",
+			status:   cloudformation.StackStatusUpdateRollbackInProgress,
+			waited:   "UPDATE_ROLLBACK_FAILED",
+			statuses: []cloudformation.StackStatus{cloudformation.StackStatusUpdateRollbackFailed, cloudformation.StackStatusUpdateRollbackComplete},
+			exists:   true,
+			calls:    []string{"wait UPDATE_ROLLBACK_IN_PROGRESS", "getStack", "continueRollback", "getStack"},
+		},
+	}
+
+	for _, c := range cases {
+		calls := make([]string, 0)
+		ops := testRecovery(&calls, c.waited, c.statuses...)
+
+		stack, exists := ops.recover(cloudformation.Stack{StackName: aws.String("stack"), StackStatus: c.status})
+
+		if exists != c.exists {
+			t.Errorf("%s: expected exists to be %t", c.name, c.exists)
+		}
+
+		if exists && nextRecoveryStep(string(stack.StackStatus)) != stepNone {
+			t.Errorf("%s: stack was left in %s", c.name, stack.StackStatus)
+		}
+
+		if !exists && stack.StackName != nil {
+			t.Errorf("%s: expected an empty stack", c.name)
+		}
+
+		if len(calls) != len(c.calls) {
+			t.Errorf("%s: expected calls %v, got %v", c.name, c.calls, calls)
+			continue
+		}
+
+		for i := range calls {
+			if calls[i] != c.calls[i] {
+				t.Errorf("%s: expected calls %v, got %v", c.name, c.calls, calls)
+				break
+			}
+		}
+	}
+}
+
+func TestFormatReviewStack(t *testing.T) {
+	output := formatReviewStack("stack", []cloudformation.ChangeSetSummary{
+		{ChangeSetName: aws.String("first"), Status: cloudformation.ChangeSetStatusCreateComplete},
+		{ChangeSetName: aws.String("second"), Status: cloudformation.ChangeSetStatusFailed},
+	})
+
+	expected := "Stack 'stack' has not been created yet and has 2 pending change sets:\n  first: CREATE_COMPLETE\n  second: FAILED\n"
+	if output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestFailedResources(t *testing.T) {
+	failed := func(id, physicalId, resourceType, reason string) cloudformation.StackResource {
+		resource := stackResource(id, resourceType, physicalId, cloudformation.ResourceStatusUpdateFailed)
+		if reason != "" {
+			resource.ResourceStatusReason = aws.String(reason)
+		}
+		return resource
+	}
+
+	// Filling the cache means that nothing is fetched from CloudFormation
+	cache := stackResources{
+		"parent": {
+			failed("Function", "function", "AWS::Lambda::Function", "Access denied"),
+			stackResource("Queue", "AWS::SQS::Queue", "queue", cloudformation.ResourceStatusUpdateComplete),
+			failed("Child", "child", "AWS::CloudFormation::Stack", ""),
+		},
+		"child": {
+			failed("Table", "table", "AWS::DynamoDB::Table", "Throttled"),
+			stackResource("Topic", "AWS::SNS::Topic", "topic", cloudformation.ResourceStatusUpdateComplete),
+		},
+	}
+
+	expected := []failedResource{
+		{id: "Function", reason: "Access denied"},
+		{id: "Child.Table", reason: "Throttled"},
+	}
+
+	actual := failedResources(cache.get("parent"), cache, "")
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+var deployRegions []string
+
+// regionDeployment is the deployment of a stack to one of the regions given by --regions
+type regionDeployment struct {
+	deployment
+	changes  []cloudformation.Change
+	status   string
+	start    time.Time
+	duration time.Duration
+	err      error
+}
+
+// inEachRegion runs fn for each deployment in parallel, recording any panic as the deployment's error.
+// If the user pressed Ctrl-C, it panics once every fn has returned.
+func inEachRegion(deployments []*regionDeployment, fn func(*regionDeployment)) {
+	var wg sync.WaitGroup
+
+	for _, rd := range deployments {
+		if rd.err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(rd *regionDeployment) {
+			defer wg.Done()
+
+			rd.err = catch(func() {
+				fn(rd)
+			})
+		}(rd)
+	}
+
+	wg.Wait()
+
+	if client.Interrupted() {
+		panic(console.ErrInterrupted)
+	}
+}
+
+// createRegionChangeSet packages the template for the deployment's region and creates a change set from it.
+// Stacks that need to be waited for or recovered must be deployed on their own.
+func createRegionChangeSet(rd *regionDeployment, fn string, source cfnTemplate.Template, paramValues, tags map[string]string, provenanceAdded map[string]bool, capabilityNames []string) {
+	api := cfn.In(rd.region)
+
+	bucket := getRainBucket(rd.region)
+
+	parsedTemplate, template := packageTemplate(fn, source, bucket, rd.region)
+
+	stack, err := api.GetStack(rd.stackName)
+	rd.stackExists = err == nil
+
+	if rd.stackExists {
+		status := string(stack.StackStatus)
+
+		if action := recoveryAction(status); action != "" {
+			panic(fmt.Errorf("Stack is %s; deploy to %s on its own to %s", status, rd.region, action))
+		}
+
+		if !strings.HasSuffix(status, "_COMPLETE") {
+			panic(fmt.Errorf("Stack could not be updated: %s", status))
+		}
+	}
+
+	parameters := getParameters(parsedTemplate, paramValues, stack.Parameters, false, false)
+
+	regionTags := make(map[string]string)
+	for key, value := range tags {
+		regionTags[key] = value
+	}
+
+	if provenance && rd.stackExists {
+		keepProvenanceTags(rd.region, stack, parsedTemplate, parameters, regionTags, provenanceAdded)
+	}
+
+	changeSetName, err := api.CreateChangeSet(template, parameters, regionTags, rd.stackName, capabilityNames, bucket, stackOptions())
+	if err != nil && changeSetIsEmpty(rd.region, rd.stackName, changeSetName) {
+		cancelChangeSet(rd.region, rd.stackName, changeSetName, rd.stackExists)
+		rd.status = statusNoChanges
+		return
+	}
+
+	rd.changeSetName = changeSetName
+	if err != nil {
+		panic(fmt.Errorf("Error while creating changeset: %s", err))
+	}
+
+	rd.changes, err = api.GetChangeSet(rd.stackName, changeSetName)
+	if err != nil {
+		panic(fmt.Errorf("Error while retrieving changeset '%s': %s", changeSetName, err))
+	}
+}
+
+// cancelRegionChangeSets deletes the change sets that were created in each region
+func cancelRegionChangeSets(deployments []*regionDeployment) {
+	for _, rd := range deployments {
+		if rd.changeSetName == "" {
+			continue
+		}
+
+		err := catch(func() {
+			cancelChangeSet(rd.region, rd.stackName, rd.changeSetName, rd.stackExists)
+		})
+		if err != nil {
+			fmt.Printf("%s: %s\n", text.Yellow(rd.region), text.Red(err.Error()))
+		}
+
+		rd.changeSetName = ""
+	}
+}
+
+// formatRegionProgress shows the status of each region's stack
+func formatRegionProgress(deployments []*regionDeployment, remaining map[string]int) string {
+	width := 0
+	for _, rd := range deployments {
+		if len(rd.region) > width {
+			width = len(rd.region)
+		}
+	}
+
+	out := strings.Builder{}
+
+	for _, rd := range deployments {
+		out.WriteString(text.Yellow(rd.region + strings.Repeat(" ", width-len(rd.region))).String())
+		out.WriteString("  ")
+
+		switch {
+		case rd.err != nil:
+			out.WriteString(text.Red(rd.err.Error()).String())
+		case rd.status == statusNoChanges:
+			out.WriteString(text.Green(rd.status).String())
+		default:
+			out.WriteString(colouriseStatus(rd.status).String())
+		}
+
+		if n := remaining[rd.region]; n > 0 && !statusIsSettled(rd.status) {
+			out.WriteString(text.Grey(fmt.Sprintf(" (%d remaining)", n)).String())
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// waitForRegions shows the progress of every region's stack until they have all settled
+func waitForRegions(deployments []*regionDeployment) {
+	spinner.Timer()
+
+	for {
+		remaining := make(map[string]int)
+		settled := true
+
+		for _, rd := range deployments {
+			if !rd.executing || rd.err != nil || statusIsSettled(rd.status) {
+				continue
+			}
+
+			api := cfn.In(rd.region)
+
+			stack, err := api.GetStack(rd.stackName)
+			if err != nil {
+				rd.err = fmt.Errorf("Operation failed: %s", err)
+				rd.duration = time.Since(rd.start).Round(time.Second)
+				continue
+			}
+
+			rd.status = string(stack.StackStatus)
+
+			if stackHasSettled(stack) {
+				rd.duration = time.Since(rd.start).Round(time.Second)
+				continue
+			}
+
+			settled = false
+
+			resources, _ := api.GetStackResources(rd.stackName)
+			for _, resource := range resources {
+				if !resourceHasSettled(resource) {
+					remaining[rd.region]++
+				}
+			}
+		}
+
+		output := formatRegionProgress(deployments, remaining)
+
+		if settled || client.Interrupted() {
+			spinner.Stop()
+			console.Clear(output)
+			return
+		}
+
+		if console.IsTTY {
+			console.Clear(output)
+			spinner.Update()
+		}
+
+		// Stop waiting early if the user presses Ctrl-C
+		select {
+		case <-client.Context().Done():
+		case <-time.After(time.Second * 2):
+		}
+	}
+}
+
+// deployToRegions deploys the stack to every region given by --regions.
+// A change set is created in each region in parallel and the changes are shown together,
+// so that the user only has to confirm the deployment once.
+func deployToRegions(fn, stackName string, source cfnTemplate.Template, paramValues, tags map[string]string, provenanceAdded map[string]bool, capabilityNames []string) {
+	if planOut != "" {
+		panic(errors.New("--plan-out cannot be used with --regions"))
+	}
+
+	fmt.Printf("Deploying '%s' as '%s' in %s:\n", filepath.Base(fn), stackName, strings.Join(deployRegions, ", "))
+
+	deployments := make([]*regionDeployment, len(deployRegions))
+	interruptible := make([]*deployment, len(deployRegions))
+	for i, region := range deployRegions {
+		deployments[i] = &regionDeployment{deployment: deployment{region: region, stackName: stackName}}
+		interruptible[i] = &deployments[i].deployment
+	}
+
+	handleInterrupts(true)
+	defer handleInterruptAll(interruptible)
+
+	// Create the change sets
+	spinner.Status(fmt.Sprintf("Creating change sets in %d regions...", len(deployments)))
+	inEachRegion(deployments, func(rd *regionDeployment) {
+		createRegionChangeSet(rd, fn, source, paramValues, tags, provenanceAdded, capabilityNames)
+	})
+	spinner.Stop()
+
+	failures := make([]string, 0)
+	for _, rd := range deployments {
+		if rd.err != nil {
+			fmt.Printf("%s: %s\n", text.Yellow(rd.region), text.Red(rd.err.Error()))
+			failures = append(failures, rd.region)
+		}
+	}
+
+	if len(failures) > 0 {
+		cancelRegionChangeSets(deployments)
+		panic(fmt.Errorf("Unable to create change sets in %s", strings.Join(failures, ", ")))
+	}
+
+	// Show the changes in each region
+	changed := 0
+	fmt.Println("CloudFormation will make the following changes:")
+	for _, rd := range deployments {
+		fmt.Printf("%s:\n", text.Yellow(rd.region))
+
+		if rd.status == statusNoChanges {
+			fmt.Println(text.Green("  No changes to deploy!"))
+		} else {
+			changed++
+			fmt.Print(indent(formatChangeSet(rd.changes), "  "))
+		}
+
+		fmt.Println()
+	}
+
+	if changed == 0 {
+		fmt.Println(text.Green("No changes to deploy!"))
+		return
+	}
+
+	if dryRun {
+		cancelRegionChangeSets(deployments)
+		ExitCode = exitChanges
+
+		fmt.Println("Dry run; nothing was deployed.")
+		return
+	}
+
+	if !force && !console.Confirm(true, fmt.Sprintf("Do you wish to deploy to %d regions?", changed)) {
+		cancelRegionChangeSets(deployments)
+		panic(errors.New("User cancelled deployment."))
+	}
+
+	// Execute the change sets
+	inEachRegion(deployments, func(rd *regionDeployment) {
+		if rd.changeSetName == "" {
+			return
+		}
+
+		rd.executing = true
+		rd.start = time.Now()
+
+		err := cfn.In(rd.region).ExecuteChangeSet(rd.stackName, rd.changeSetName, disableRollback)
+		if err != nil {
+			rd.executing = false
+			panic(fmt.Errorf("Error while executing changeset '%s': %s", rd.changeSetName, err))
+		}
+	})
+
+	waitForRegions(deployments)
+
+	if client.Interrupted() {
+		panic(console.ErrInterrupted)
+	}
+
+	// There is nothing left to clean up
+	handleInterrupts(false)
+
+	results := make([]stackResult, len(deployments))
+	for i, rd := range deployments {
+		result := stackResult{
+			name:     stackName,
+			region:   rd.region,
+			status:   rd.status,
+			duration: rd.duration,
+			err:      rd.err,
+		}
+
+		if rd.err == nil && rd.status != statusNoChanges {
+			if rd.status != "CREATE_COMPLETE" && rd.status != "UPDATE_COMPLETE" {
+				result.err = errors.New("Failed deployment")
+				result.diagnosis = diagnoseFailure(rd.region, stackName)
+			} else {
+				result.err = catch(func() {
+					updateStackSettings(rd.region, stackName)
+				})
+			}
+		}
+
+		results[i] = result
+	}
+
+	reportResults(results, "deploy")
+}
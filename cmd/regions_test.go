@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/console"
+)
+
+func init() {
+	console.IsTTY = false
+}
+
+func regionDeployments(regions ...string) []*regionDeployment {
+	out := make([]*regionDeployment, len(regions))
+	for i, region := range regions {
+		out[i] = &regionDeployment{deployment: deployment{region: region, stackName: "stack"}}
+	}
+
+	return out
+}
+
+func TestFormatRegionProgress(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    []string
+		err       []error
+		remaining map[string]int
+		expected  string
+	}{
+		{
+			name:     "aligned",
+			status:   []string{"CREATE_COMPLETE", "UPDATE_COMPLETE"},
+			expected: "us-east-1       CREATE_COMPLETE\nap-southeast-2  UPDATE_COMPLETE\n",
+		},
+		{
+			name:      "remaining",
+			status:    []string{"UPDATE_IN_PROGRESS", "CREATE_IN_PROGRESS"},
+			remaining: map[string]int{"us-east-1": 3, "ap-southeast-2": 1},
+			expected:  "us-east-1       UPDATE_IN_PROGRESS (3 remaining)\nap-southeast-2  CREATE_IN_PROGRESS (1 remaining)\n",
+		},
+		{
+			name:      "settled stacks have nothing remaining",
+			status:    []string{"UPDATE_ROLLBACK_COMPLETE", "UPDATE_IN_PROGRESS"},
+			remaining: map[string]int{"us-east-1": 2},
+			expected:  "us-east-1       UPDATE_ROLLBACK_COMPLETE\nap-southeast-2  UPDATE_IN_PROGRESS\n",
+		},
+		{
+			name:     "no changes and errors",
+			status:   []string{statusNoChanges, ""},
+			err:      []error{nil, errors.New("Operation failed: throttled")},
+			expected: "us-east-1       NO_CHANGES\nap-southeast-2  Operation failed: throttled\n",
+		},
+	}
+
+	for _, c := range cases {
+		deployments := regionDeployments("us-east-1", "ap-southeast-2")
+		for i, rd := range deployments {
+			rd.status = c.status[i]
+			if c.err != nil {
+				rd.err = c.err[i]
+			}
+		}
+
+		actual := formatRegionProgress(deployments, c.remaining)
+		if actual != c.expected {
+			t.Errorf("%s: expected:\n%q\ngot:\n%q", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestInEachRegion(t *testing.T) {
+	deployments := regionDeployments("us-east-1", "eu-west-1", "ap-southeast-2", "sa-east-1")
+	deployments[3].err = errors.New("already failed")
+
+	inEachRegion(deployments, func(rd *regionDeployment) {
+		if rd.region == "eu-west-1" {
+			panic(errors.New("no bucket"))
+		}
+
+		rd.changeSetName = "cs-" + rd.region
+	})
+
+	for _, rd := range deployments[:3] {
+		switch {
+		case rd.region == "eu-west-1":
+			if rd.err == nil || rd.err.Error() != "no bucket" {
+				t.Errorf("%s: expected the panic to be recorded, got %v", rd.region, rd.err)
+			}
+		case rd.err != nil:
+			t.Errorf("%s: unexpected error: %s", rd.region, rd.err)
+		case rd.changeSetName != "cs-"+rd.region:
+			t.Errorf("%s: fn was not run", rd.region)
+		}
+	}
+
+	if deployments[3].changeSetName != "" || deployments[3].err.Error() != "already failed" {
+		t.Error("fn should not run for regions that have already failed")
+	}
+}
@@ -8,19 +8,49 @@ import (
 	"github.com/aws-cloudformation/rain/console"
 	"github.com/aws-cloudformation/rain/console/spinner"
 	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/spf13/cobra"
 )
 
 var forceRm = false
+var disableTerminationProtection = false
+
+// checkTerminationProtection disables termination protection if --disable-termination-protection was set
+// and otherwise refuses to delete a protected stack
+func checkTerminationProtection(region string, stack cloudformation.Stack) {
+	if stack.EnableTerminationProtection == nil || !*stack.EnableTerminationProtection {
+		return
+	}
+
+	if !disableTerminationProtection {
+		panic(fmt.Errorf("Stack '%s' has termination protection enabled. Use --disable-termination-protection to delete it.", *stack.StackName))
+	}
+
+	err := cfn.In(region).SetTerminationProtection(*stack.StackName, false)
+	if err != nil {
+		panic(fmt.Errorf("Unable to disable termination protection for stack '%s': %s", *stack.StackName, err))
+	}
+}
 
 var rmCmd = &cobra.Command{
-	Use:                   "rm <stack>",
-	Short:                 "Delete a running CloudFormation stack",
-	Long:                  "Deletes the CloudFormation stack named <stack> and waits for the action to complete.",
-	Args:                  cobra.ExactArgs(1),
+	Use:   "rm <stack>",
+	Short: "Delete a running CloudFormation stack",
+	Long:  "Deletes the CloudFormation stack named <stack> and waits for the action to complete.\n\nWith --file, deletes every stack described in a rain.yaml manifest instead, in the reverse of the order they are deployed in.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if manifestFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Aliases:               []string{"remove", "del", "delete"},
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
+		if manifestFile != "" {
+			rmManifest(manifestFile)
+			return
+		}
+
 		stackName := args[0]
 
 		spinner.Status("Checking stack status...")
@@ -42,9 +72,11 @@ var rmCmd = &cobra.Command{
 
 		spinner.Stop()
 
+		checkTerminationProtection("", stack)
+
 		fmt.Printf("Deleting '%s' in %s...\n", stackName, client.Config().Region)
 
-		err = cfn.DeleteStack(stackName)
+		err = cfn.DeleteStack(stackName, roleArn)
 		if err != nil {
 			panic(fmt.Errorf("Unable to delete stack '%s': %s", stackName, err))
 		}
@@ -63,5 +95,8 @@ var rmCmd = &cobra.Command{
 
 func init() {
 	rmCmd.Flags().BoolVarP(&forceRm, "force", "f", false, "Do not ask; just delete")
+	rmCmd.Flags().StringVarP(&manifestFile, "file", "m", "", "Delete all of the stacks described in a rain.yaml manifest.")
+	rmCmd.Flags().StringVar(&roleArn, "role-arn", "", "The ARN of an IAM role that CloudFormation uses to delete the stack.")
+	rmCmd.Flags().BoolVar(&disableTerminationProtection, "disable-termination-protection", false, "Disable termination protection before deleting the stack.")
 	Root.AddCommand(rmCmd)
 }
@@ -7,6 +7,24 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Exit codes for commands that report whether anything would change
+const (
+	exitNoChanges = 0
+	exitChanges   = 2
+)
+
+// ExitCode is the status that rain should exit with when a command succeeds
+var ExitCode = exitNoChanges
+
+// ErrorExitCode returns the status that rain should exit with when a command fails
+func ErrorExitCode() int {
+	if ExitCode == exitInterrupted {
+		return exitInterrupted
+	}
+
+	return 1
+}
+
 // Root represents the base command when called without any subcommands
 var Root = &cobra.Command{
 	Use:  "rain",
@@ -22,6 +40,8 @@ func init() {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the Root.
 func Execute() {
+	listenForInterrupts()
+
 	if err := Root.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,685 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/capabilities"
+	"github.com/aws-cloudformation/rain/cfn/diff"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws-cloudformation/rain/cfn/pkg"
+	"github.com/aws-cloudformation/rain/client"
+	"github.com/aws-cloudformation/rain/client/cfn"
+	"github.com/aws-cloudformation/rain/config"
+	"github.com/aws-cloudformation/rain/console"
+	"github.com/aws-cloudformation/rain/console/spinner"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/spf13/cobra"
+)
+
+var stackSetAccounts []string
+var stackSetRegions []string
+var maxConcurrent = ""
+var failureTolerance = ""
+var adminRoleARN = ""
+var executionRoleName = ""
+var retainStacks = false
+
+func colouriseInstanceStatus(status string) text.Text {
+	switch status {
+	case "CURRENT", "SUCCEEDED":
+		return text.Green(status)
+	case "OUTDATED", "PENDING", "RUNNING", "QUEUED", "STOPPING":
+		return text.Orange(status)
+	case "INOPERABLE", "FAILED":
+		return text.Red(status)
+	default:
+		return text.Grey(status)
+	}
+}
+
+// parseCountOrPercent parses a value such as "5" or "25%"
+func parseCountOrPercent(flag, value string) (*int64, *int64) {
+	if value == "" {
+		return nil, nil
+	}
+
+	isPercent := strings.HasSuffix(value, "%")
+
+	n, err := strconv.ParseInt(strings.TrimSuffix(value, "%"), 10, 64)
+	if err != nil || n < 0 {
+		panic(fmt.Errorf("Invalid value for --%s: '%s'. Use a number or a percentage such as 25%%.", flag, value))
+	}
+
+	if isPercent {
+		return nil, &n
+	}
+
+	return &n, nil
+}
+
+func getOperationPreferences() cloudformation.StackSetOperationPreferences {
+	prefs := cloudformation.StackSetOperationPreferences{
+		RegionOrder: stackSetRegions,
+	}
+
+	prefs.MaxConcurrentCount, prefs.MaxConcurrentPercentage = parseCountOrPercent("max-concurrent", maxConcurrent)
+	prefs.FailureToleranceCount, prefs.FailureTolerancePercentage = parseCountOrPercent("failure-tolerance", failureTolerance)
+
+	return prefs
+}
+
+// instanceKey identifies a stack instance by account and region
+type instanceKey struct {
+	account string
+	region  string
+}
+
+func formatInstances(instances []cloudformation.StackInstanceSummary) string {
+	byAccount := make(map[string][]cloudformation.StackInstanceSummary)
+	accounts := make([]string, 0)
+
+	for _, instance := range instances {
+		account := *instance.Account
+		if _, ok := byAccount[account]; !ok {
+			accounts = append(accounts, account)
+		}
+		byAccount[account] = append(byAccount[account], instance)
+	}
+	sort.Strings(accounts)
+
+	out := strings.Builder{}
+	for _, account := range accounts {
+		out.WriteString(fmt.Sprintf("  %s:\n", text.Yellow(account)))
+
+		group := byAccount[account]
+		sort.Slice(group, func(i, j int) bool {
+			return *group[i].Region < *group[j].Region
+		})
+
+		for _, instance := range group {
+			out.WriteString(fmt.Sprintf("    %s: %s", *instance.Region, colouriseInstanceStatus(string(instance.Status))))
+			if instance.StatusReason != nil && *instance.StatusReason != "" {
+				out.WriteString(" " + text.White(fmt.Sprintf("%q", *instance.StatusReason)).String())
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	return out.String()
+}
+
+func formatOperationResults(results []cloudformation.StackSetOperationResultSummary) string {
+	sort.Slice(results, func(i, j int) bool {
+		if *results[i].Account == *results[j].Account {
+			return *results[i].Region < *results[j].Region
+		}
+
+		return *results[i].Account < *results[j].Account
+	})
+
+	out := strings.Builder{}
+	account := ""
+	for _, result := range results {
+		if *result.Account != account {
+			account = *result.Account
+			out.WriteString(fmt.Sprintf("  %s:\n", text.Yellow(account)))
+		}
+
+		out.WriteString(fmt.Sprintf("    %s: %s", *result.Region, colouriseInstanceStatus(string(result.Status))))
+		if result.StatusReason != nil && *result.StatusReason != "" {
+			out.WriteString(" " + text.White(fmt.Sprintf("%q", *result.StatusReason)).String())
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+func operationHasSettled(operation cloudformation.StackSetOperation) bool {
+	switch operation.Status {
+	case cloudformation.StackSetOperationStatusRunning, cloudformation.StackSetOperationStatusStopping, "QUEUED":
+		return false
+	}
+
+	return true
+}
+
+// waitForOperation displays the progress of a stack set operation until it finishes
+func waitForOperation(stackSetName, operationId string) string {
+	spinner.Timer()
+
+	for {
+		operation, err := cfn.GetStackSetOperation(stackSetName, operationId)
+		if err != nil {
+			panic(fmt.Errorf("Unable to get operation '%s': %s", operationId, err))
+		}
+
+		results, _ := cfn.ListStackSetOperationResults(stackSetName, operationId)
+		// We ignore errors because it just means we'll list no instances
+
+		output := fmt.Sprintf("%s:  # %s %s\n%s",
+			stackSetName,
+			strings.ToLower(string(operation.Action)),
+			colouriseInstanceStatus(string(operation.Status)),
+			formatOperationResults(results),
+		)
+
+		if console.IsTTY {
+			console.Clear(output)
+			spinner.Update()
+		}
+
+		if operationHasSettled(operation) {
+			spinner.Stop()
+			console.Clear(output)
+			return string(operation.Status)
+		}
+
+		time.Sleep(time.Second * 2)
+	}
+}
+
+func checkOperation(stackSetName, operationId string) {
+	status := waitForOperation(stackSetName, operationId)
+	if status != string(cloudformation.StackSetOperationStatusSucceeded) {
+		panic(fmt.Errorf("Stack set operation on '%s' finished with status %s", stackSetName, status))
+	}
+}
+
+var stackSetCmd = &cobra.Command{
+	Use:   "stackset <command>",
+	Short: "Manage CloudFormation stack sets",
+	Long:  "Deploys, lists, watches and deletes CloudFormation stack sets and their instances across accounts and regions.",
+}
+
+var stackSetDeployCmd = &cobra.Command{
+	Use:                   "deploy <template> <stackset>",
+	Short:                 "Deploy a CloudFormation stack set from a local template",
+	Long:                  "Creates or updates the stack set named <stackset> from the template file <template>, then adds instances to any of the accounts and regions given by --accounts and --regions that don't have one yet.",
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		fn := args[0]
+		stackSetName := args[1]
+
+		prefs := getOperationPreferences()
+
+		if len(stackSetAccounts) > 0 != (len(stackSetRegions) > 0) {
+			panic(errors.New("--accounts and --regions must be used together"))
+		}
+
+		source, err := parse.File(fn)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
+		}
+
+		paramValues, fileTags := getParameterValues(source)
+
+		parsedTags := parseTags(tags)
+		for key, value := range fileTags {
+			if _, ok := parsedTags[key]; !ok {
+				parsedTags[key] = value
+			}
+		}
+
+		home := client.Config().Region
+
+		fmt.Printf("Deploying '%s' as stack set '%s' from %s:\n", filepath.Base(fn), stackSetName, home)
+
+		fmt.Printf("Checking current status of stack set '%s'... ", stackSetName)
+
+		stackSet, err := cfn.GetStackSet(stackSetName)
+		if err != nil && err != cfn.ErrStackSetNotFound {
+			panic(fmt.Errorf("Unable to get stack set '%s': %s", stackSetName, err))
+		}
+		stackSetExists := err == nil
+
+		existing := make(map[instanceKey]bool)
+		regions := append([]string{}, stackSetRegions...)
+		if stackSetExists {
+			instances, err := cfn.ListStackInstances(stackSetName)
+			if err != nil {
+				panic(fmt.Errorf("Unable to list instances of stack set '%s': %s", stackSetName, err))
+			}
+
+			for _, instance := range instances {
+				existing[instanceKey{*instance.Account, *instance.Region}] = true
+				regions = append(regions, *instance.Region)
+			}
+		}
+
+		console.ClearLine()
+
+		checkArtifactRegions(fn, source, home, regions)
+
+		fmt.Print("Preparing template... ")
+
+		bucket := getRainBucket("")
+
+		parsedTemplate, template := packageTemplate(fn, source, bucket, "")
+
+		config.Debugf("Packaged template:\n%s", template)
+
+		if stackSetExists && stackSet.TemplateBody != nil {
+			oldTemplate, _ := parse.String(*stackSet.TemplateBody)
+			d := oldTemplate.Diff(parsedTemplate)
+
+			console.ClearLine()
+			if d.Mode() == diff.Unchanged {
+				fmt.Println("The stack set's template has not changed.")
+			} else if !force && console.Confirm(true, fmt.Sprintf("Stack set '%s' exists. Do you wish to compare the CloudFormation templates?", stackSetName)) {
+				fmt.Print(colouriseDiff(d, false))
+			}
+		}
+		console.ClearLine()
+
+		// Work out which instances will be added and updated
+		newInstances := make([]instanceKey, 0)
+		preview := strings.Builder{}
+		for key := range existing {
+			preview.WriteString(text.Orange(fmt.Sprintf("(|) %s %s\n", key.account, key.region)).String())
+		}
+		for _, account := range stackSetAccounts {
+			for _, region := range stackSetRegions {
+				if existing[instanceKey{account, region}] {
+					continue
+				}
+
+				newInstances = append(newInstances, instanceKey{account, region})
+				preview.WriteString(text.Green(fmt.Sprintf("(+) %s %s\n", account, region)).String())
+			}
+		}
+
+		if !stackSetExists && len(newInstances) == 0 {
+			fmt.Println("No accounts and regions given; the stack set will be created without any instances.")
+		}
+
+		var oldParams []cloudformation.Parameter
+		if stackSetExists {
+			oldParams = stackSet.Parameters
+		}
+
+		parameters := getParameters(parsedTemplate, paramValues, oldParams, false, !force)
+
+		config.Debugf("Parameters: %s", parameters)
+
+		requiredCapabilities := capabilities.RequiredIn(source, filepath.Dir(fn))
+		if len(requiredCapabilities) > 0 {
+			fmt.Println("This template requires the following capabilities:")
+			fmt.Print(formatCapabilities(requiredCapabilities))
+
+			if !force && !console.Confirm(true, "Do you wish to grant these capabilities?") {
+				panic(errors.New("User cancelled deployment."))
+			}
+		}
+
+		if preview.Len() > 0 {
+			fmt.Println("The following stack instances will be deployed:")
+			fmt.Print(sortLines(preview.String()))
+		}
+
+		if !force && !console.Confirm(true, "Do you wish to continue?") {
+			panic(errors.New("User cancelled deployment."))
+		}
+
+		if stackSetExists {
+			spinner.Status("Updating stack set...")
+			operationId, err := cfn.UpdateStackSet(stackSetName, template, parameters, parsedTags, capabilities.Names(requiredCapabilities), adminRoleARN, executionRoleName, bucket, prefs)
+			spinner.Stop()
+			if err != nil {
+				panic(fmt.Errorf("Error while updating stack set '%s': %s", stackSetName, err))
+			}
+
+			checkOperation(stackSetName, operationId)
+		} else {
+			spinner.Status("Creating stack set...")
+			err := cfn.CreateStackSet(stackSetName, template, parameters, parsedTags, capabilities.Names(requiredCapabilities), adminRoleARN, executionRoleName, bucket)
+			spinner.Stop()
+			if err != nil {
+				panic(fmt.Errorf("Error while creating stack set '%s': %s", stackSetName, err))
+			}
+		}
+
+		for _, batch := range batchInstances(newInstances) {
+			spinner.Status("Creating stack instances...")
+			operationId, err := cfn.CreateStackInstances(stackSetName, batch.accounts, batch.regions, prefs)
+			spinner.Stop()
+			if err != nil {
+				panic(fmt.Errorf("Error while creating instances of stack set '%s': %s", stackSetName, err))
+			}
+
+			checkOperation(stackSetName, operationId)
+		}
+
+		fmt.Println(text.Green("Successfully deployed stack set " + stackSetName))
+		fmt.Println()
+	},
+}
+
+var stackSetLsCmd = &cobra.Command{
+	Use:                   "ls <stackset>",
+	Short:                 "List stack sets",
+	Long:                  "Displays a list of all active stack sets or the instances of <stackset> if provided.",
+	Args:                  cobra.MaximumNArgs(1),
+	Aliases:               []string{"list"},
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			stackSetName := args[0]
+
+			spinner.Status(fmt.Sprintf("Fetching stack set '%s'...", stackSetName))
+			stackSet, err := cfn.GetStackSet(stackSetName)
+			if err != nil {
+				panic(fmt.Errorf("Failed to list stack set '%s': %s", stackSetName, err))
+			}
+
+			instances, err := cfn.ListStackInstances(stackSetName)
+			if err != nil {
+				panic(fmt.Errorf("Failed to list instances of stack set '%s': %s", stackSetName, err))
+			}
+			spinner.Stop()
+
+			fmt.Printf("%s:  # %s\n", *stackSet.StackSetName, colouriseInstanceStatus(string(stackSet.Status)))
+
+			if len(stackSet.Parameters) > 0 {
+				fmt.Println("  Parameters:")
+				for _, param := range stackSet.Parameters {
+					fmt.Printf("    %s: %s\n", *param.ParameterKey, text.Yellow(*param.ParameterValue))
+				}
+			}
+
+			if len(instances) > 0 {
+				fmt.Println("  Instances:")
+				fmt.Print(indent(formatInstances(instances), "  "))
+			}
+
+			return
+		}
+
+		spinner.Status("Fetching stack sets...")
+		stackSets, err := cfn.ListStackSets()
+		if err != nil {
+			panic(fmt.Errorf("Failed to list stack sets: %s", err))
+		}
+		spinner.Stop()
+
+		sort.Slice(stackSets, func(i, j int) bool {
+			return *stackSets[i].StackSetName < *stackSets[j].StackSetName
+		})
+
+		fmt.Println(text.Yellow(fmt.Sprintf("CloudFormation stack sets in %s:", client.Config().Region)))
+		for _, stackSet := range stackSets {
+			fmt.Printf("  %s: %s\n", *stackSet.StackSetName, colouriseInstanceStatus(string(stackSet.Status)))
+		}
+		fmt.Println()
+	},
+}
+
+var stackSetRmCmd = &cobra.Command{
+	Use:                   "rm <stackset>",
+	Short:                 "Delete a stack set or some of its instances",
+	Long:                  "Deletes the instances of <stackset> in the accounts and regions given by --accounts and --regions. If neither is given, deletes all of the stack set's instances and then the stack set itself.",
+	Args:                  cobra.ExactArgs(1),
+	Aliases:               []string{"remove", "del", "delete"},
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		stackSetName := args[0]
+
+		prefs := getOperationPreferences()
+
+		spinner.Status("Checking stack set status...")
+		instances, err := cfn.ListStackInstances(stackSetName)
+		if err != nil {
+			panic(fmt.Errorf("Unable to delete stack set '%s': %s", stackSetName, err))
+		}
+		spinner.Stop()
+
+		deleteAll := len(stackSetAccounts) == 0 && len(stackSetRegions) == 0
+
+		accountFilter := make(map[string]bool)
+		for _, account := range stackSetAccounts {
+			accountFilter[account] = true
+		}
+		regionFilter := make(map[string]bool)
+		for _, region := range stackSetRegions {
+			regionFilter[region] = true
+		}
+
+		// Find the instances to delete
+		keys := make([]instanceKey, 0)
+		targets := make([]cloudformation.StackInstanceSummary, 0)
+		for _, instance := range instances {
+			if (len(accountFilter) > 0 && !accountFilter[*instance.Account]) || (len(regionFilter) > 0 && !regionFilter[*instance.Region]) {
+				continue
+			}
+
+			keys = append(keys, instanceKey{*instance.Account, *instance.Region})
+			targets = append(targets, instance)
+		}
+
+		if len(targets) > 0 {
+			fmt.Println("The following stack instances will be deleted:")
+			fmt.Print(formatInstances(targets))
+		}
+
+		if deleteAll {
+			fmt.Printf("The stack set '%s' will be deleted.\n", stackSetName)
+		} else if len(targets) == 0 {
+			fmt.Println("No matching stack instances to delete.")
+			return
+		}
+
+		if !forceRm && !console.Confirm(false, "Are you sure you want to continue?") {
+			panic(fmt.Errorf("User cancelled deletion of stack set '%s'.", stackSetName))
+		}
+
+		for _, batch := range batchInstances(keys) {
+			spinner.Status("Deleting stack instances...")
+			operationId, err := cfn.DeleteStackInstances(stackSetName, batch.accounts, batch.regions, retainStacks, prefs)
+			spinner.Stop()
+			if err != nil {
+				panic(fmt.Errorf("Unable to delete instances of stack set '%s': %s", stackSetName, err))
+			}
+
+			checkOperation(stackSetName, operationId)
+		}
+
+		if deleteAll {
+			err = cfn.DeleteStackSet(stackSetName)
+			if err != nil {
+				panic(fmt.Errorf("Unable to delete stack set '%s': %s", stackSetName, err))
+			}
+
+			fmt.Println(text.Green("Successfully deleted stack set " + stackSetName))
+		} else {
+			fmt.Println(text.Green("Successfully deleted instances of stack set " + stackSetName))
+		}
+
+		fmt.Println()
+	},
+}
+
+var stackSetWatchCmd = &cobra.Command{
+	Use:                   "watch <stackset> (<operation>)",
+	Short:                 "Display an updating view of a stack set operation",
+	Long:                  "Repeatedly displays the status of each stack instance affected by the most recent operation on <stackset>, or by <operation> if provided.",
+	Args:                  cobra.RangeArgs(1, 2),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		stackSetName := args[0]
+
+		var operationId string
+		if len(args) > 1 {
+			operationId = args[1]
+		} else {
+			operations, err := cfn.ListStackSetOperations(stackSetName)
+			if err != nil {
+				panic(fmt.Errorf("Error watching stack set '%s': %s", stackSetName, err))
+			}
+
+			if len(operations) == 0 {
+				fmt.Println("Stack set has no operations to watch.")
+				return
+			}
+
+			operationId = *operations[0].OperationId
+		}
+
+		fmt.Println("Final operation status:", colouriseInstanceStatus(waitForOperation(stackSetName, operationId)))
+	},
+}
+
+// checkArtifactRegions refuses to deploy a template that refers to local files to stack instances outside of home.
+// The files are uploaded to rain's bucket in home, and some, such as Lambda code, must be in the same region as the stack that uses them.
+func checkArtifactRegions(fn string, source cfnTemplate.Template, home string, regions []string) {
+	paths := pkg.LocalPaths(source)
+	if len(paths) == 0 {
+		return
+	}
+
+	others := make(map[string]bool)
+	for _, region := range regions {
+		if region != home {
+			others[region] = true
+		}
+	}
+
+	if len(others) == 0 {
+		return
+	}
+
+	panic(fmt.Errorf("Template '%s' refers to local files (%s), which would be uploaded to %s and could not be used by stack instances in %s. Upload them to a bucket in each region and refer to them by S3 location instead.",
+		fn, strings.Join(paths, ", "), home, strings.Join(sortedSet(others), ", ")))
+}
+
+// instanceBatch is a set of accounts that all target the same regions
+type instanceBatch struct {
+	accounts []string
+	regions  []string
+}
+
+// batchInstances groups instances into batches that can each be handled
+// by a single operation, which always targets every region in every account
+func batchInstances(keys []instanceKey) []instanceBatch {
+	regionsByAccount := make(map[string]map[string]bool)
+	for _, key := range keys {
+		if _, ok := regionsByAccount[key.account]; !ok {
+			regionsByAccount[key.account] = make(map[string]bool)
+		}
+		regionsByAccount[key.account][key.region] = true
+	}
+
+	accounts := make([]string, 0, len(regionsByAccount))
+	for account := range regionsByAccount {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	batches := make([]instanceBatch, 0)
+	byRegions := make(map[string]int)
+	for _, account := range accounts {
+		regions := orderedRegions(regionsByAccount[account])
+		id := strings.Join(regions, ",")
+
+		if i, ok := byRegions[id]; ok {
+			batches[i].accounts = append(batches[i].accounts, account)
+			continue
+		}
+
+		byRegions[id] = len(batches)
+		batches = append(batches, instanceBatch{[]string{account}, regions})
+	}
+
+	return batches
+}
+
+// orderedRegions returns the regions in the order given by --regions
+func orderedRegions(regions map[string]bool) []string {
+	out := make([]string, 0)
+
+	for _, region := range stackSetRegions {
+		if regions[region] {
+			out = append(out, region)
+		}
+	}
+
+	for _, region := range sortedSet(regions) {
+		if !stringIn(region, out) {
+			out = append(out, region)
+		}
+	}
+
+	return out
+}
+
+func sortedSet(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for key := range set {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func stringIn(s string, list []string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortLines sorts the lines of s
+func sortLines(s string) string {
+	parts := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	sort.Strings(parts)
+
+	return strings.Join(parts, "\n") + "\n"
+}
+
+func indent(s, prefix string) string {
+	parts := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, part := range parts {
+		parts[i] = prefix + part
+	}
+
+	return strings.Join(parts, "\n") + "\n"
+}
+
+func addTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&stackSetAccounts, "accounts", []string{}, "Accounts to target. Use the format 111111111111,222222222222.")
+	cmd.Flags().StringSliceVar(&stackSetRegions, "regions", []string{}, "Regions to target, in the order they should be deployed to. Use the format us-east-1,eu-west-1.")
+	cmd.Flags().StringVar(&maxConcurrent, "max-concurrent", "", "The number or percentage of accounts to deploy to at once, for example 5 or 25%.")
+	cmd.Flags().StringVar(&failureTolerance, "failure-tolerance", "", "The number or percentage of accounts that may fail per region before the operation stops, for example 1 or 10%.")
+}
+
+func init() {
+	addTargetFlags(stackSetDeployCmd)
+	stackSetDeployCmd.Flags().BoolVarP(&force, "force", "f", false, "Don't ask questions; just deploy.")
+	stackSetDeployCmd.Flags().StringSliceVar(&tags, "tags", []string{}, "Add tags to the stack set. Use the format key1=value1,key2=value2.")
+	stackSetDeployCmd.Flags().StringVar(&paramsFile, "params", "", "Read parameter values from a file. The file may be a CloudFormation parameters file, a CodePipeline template configuration file, or a YAML map of parameter names to values.")
+	stackSetDeployCmd.Flags().StringArrayVar(&paramFlags, "param", []string{}, "Set a parameter value. Use the format key=value. May be repeated.")
+	stackSetDeployCmd.Flags().StringVar(&adminRoleARN, "admin-role-arn", "", "ARN of the role that CloudFormation uses to administer the stack set.")
+	stackSetDeployCmd.Flags().StringVar(&executionRoleName, "execution-role-name", "", "Name of the role that CloudFormation assumes in each target account.")
+
+	addTargetFlags(stackSetRmCmd)
+	stackSetRmCmd.Flags().BoolVarP(&forceRm, "force", "f", false, "Do not ask; just delete")
+	stackSetRmCmd.Flags().BoolVar(&retainStacks, "retain-stacks", false, "Remove the instances from the stack set but keep their stacks.")
+
+	stackSetCmd.AddCommand(stackSetDeployCmd)
+	stackSetCmd.AddCommand(stackSetLsCmd)
+	stackSetCmd.AddCommand(stackSetRmCmd)
+	stackSetCmd.AddCommand(stackSetWatchCmd)
+	Root.AddCommand(stackSetCmd)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestParseCountOrPercent(t *testing.T) {
+	count, percent := parseCountOrPercent("max-concurrent", "")
+	if count != nil || percent != nil {
+		t.Error("Expected nothing for an empty value")
+	}
+
+	count, percent = parseCountOrPercent("max-concurrent", "5")
+	if count == nil || *count != 5 || percent != nil {
+		t.Errorf("Expected a count of 5, got %v %v", count, percent)
+	}
+
+	count, percent = parseCountOrPercent("max-concurrent", "25%")
+	if percent == nil || *percent != 25 || count != nil {
+		t.Errorf("Expected 25%%, got %v %v", count, percent)
+	}
+
+	for _, value := range []string{"-1", "five", "%"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected '%s' to be rejected", value)
+				}
+			}()
+
+			parseCountOrPercent("max-concurrent", value)
+		}()
+	}
+}
+
+func TestBatchInstances(t *testing.T) {
+	stackSetRegions = []string{"eu-west-1", "us-east-1"}
+	defer func() { stackSetRegions = nil }()
+
+	batches := batchInstances([]instanceKey{
+		{"222222222222", "us-east-1"},
+		{"111111111111", "us-east-1"},
+		{"111111111111", "eu-west-1"},
+		{"333333333333", "ap-southeast-2"},
+		{"222222222222", "eu-west-1"},
+	})
+
+	expected := []instanceBatch{
+		{[]string{"111111111111", "222222222222"}, []string{"eu-west-1", "us-east-1"}},
+		{[]string{"333333333333"}, []string{"ap-southeast-2"}},
+	}
+
+	if !reflect.DeepEqual(batches, expected) {
+		t.Errorf("Expected %v, got %v", expected, batches)
+	}
+}
+
+func TestOrderedRegions(t *testing.T) {
+	stackSetRegions = []string{"us-west-2", "eu-west-1"}
+	defer func() { stackSetRegions = nil }()
+
+	actual := orderedRegions(map[string]bool{"eu-west-1": true, "ap-south-1": true, "us-west-2": true})
+	expected := []string{"us-west-2", "eu-west-1", "ap-south-1"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestCheckArtifactRegions(t *testing.T) {
+	local, _ := parse.String(`
+Resources:
+  Function:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code: src
+`)
+
+	remote, _ := parse.String(`
+Resources:
+  Function:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code:
+        S3Bucket: bucket
+        S3Key: code.zip
+`)
+
+	// These should all be allowed
+	checkArtifactRegions("local.yaml", local, "us-east-1", []string{"us-east-1"})
+	checkArtifactRegions("local.yaml", local, "us-east-1", nil)
+	checkArtifactRegions("remote.yaml", remote, "us-east-1", []string{"us-east-1", "eu-west-1"})
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected local files to be refused for other regions")
+		}
+
+		if err := r.(error).Error(); !strings.Contains(err, "(src)") || !strings.Contains(err, "in eu-west-1") {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	}()
+
+	checkArtifactRegions("local.yaml", local, "us-east-1", []string{"us-east-1", "eu-west-1", "eu-west-1"})
+}
+
+func TestFormatInstances(t *testing.T) {
+	instance := func(account, region, status, reason string) cloudformation.StackInstanceSummary {
+		summary := cloudformation.StackInstanceSummary{
+			Account: aws.String(account),
+			Region:  aws.String(region),
+			Status:  cloudformation.StackInstanceStatus(status),
+		}
+
+		if reason != "" {
+			summary.StatusReason = aws.String(reason)
+		}
+
+		return summary
+	}
+
+	actual := formatInstances([]cloudformation.StackInstanceSummary{
+		instance("222222222222", "us-east-1", "CURRENT", ""),
+		instance("111111111111", "us-east-1", "OUTDATED", "User initiated stop"),
+		instance("111111111111", "eu-west-1", "CURRENT", ""),
+	})
+
+	expected := `  111111111111:
+    eu-west-1: CURRENT
+    us-east-1: OUTDATED "User initiated stop"
+  222222222222:
+    us-east-1: CURRENT
+`
+
+	if actual != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, actual)
+	}
+}
+
+func TestOperationHasSettled(t *testing.T) {
+	cases := map[cloudformation.StackSetOperationStatus]bool{
+		cloudformation.StackSetOperationStatusRunning:  false,
+		cloudformation.StackSetOperationStatusStopping: false,
+		"QUEUED": false,
+		cloudformation.StackSetOperationStatusSucceeded: true,
+		cloudformation.StackSetOperationStatusFailed:    true,
+		cloudformation.StackSetOperationStatusStopped:   true,
+	}
+
+	for status, expected := range cases {
+		if actual := operationHasSettled(cloudformation.StackSetOperation{Status: status}); actual != expected {
+			t.Errorf("%s: expected %t", status, expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	cfnTemplate "github.com/aws-cloudformation/rain/cfn"
+	"github.com/aws-cloudformation/rain/cfn/format"
+	"github.com/aws-cloudformation/rain/cfn/parse"
+	cfntags "github.com/aws-cloudformation/rain/cfn/tags"
+	"github.com/aws-cloudformation/rain/console/text"
+	"github.com/spf13/cobra"
+)
+
+var requiredTags []string
+var addTags []string
+var writeTags = false
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <template>",
+	Short: "Check or add tags on the resources in a template",
+	Long: `Lists the taggable resources in the CloudFormation template <template> that are missing any of the tags named by --require.
+
+With --add, rain adds the given tags to every taggable resource that doesn't already have them and outputs the updated template.
+If --require is also given, the template is only output if every taggable resource has the required tags once the new tags have been added.`,
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		fn := args[0]
+
+		if len(requiredTags) == 0 && len(addTags) == 0 {
+			panic(errors.New("Nothing to do; use --require to check for tags or --add to add them"))
+		}
+
+		t, err := parse.File(fn)
+		if err != nil {
+			panic(fmt.Errorf("Unable to parse template '%s': %s", fn, err))
+		}
+
+		if len(addTags) > 0 {
+			failed := cfntags.Add(t, parseTags(addTags))
+			for _, name := range failed {
+				fmt.Println(text.Orange(fmt.Sprintf("Unable to add tags to '%s' as its tags are not a simple list or map", name)))
+			}
+		}
+
+		missing := cfntags.Missing(t, requiredTags)
+		if len(missing) == 0 {
+			if len(addTags) > 0 {
+				writeTaggedTemplate(fn, t)
+			} else {
+				fmt.Println(text.Green("All taggable resources have the required tags"))
+			}
+
+			return
+		}
+
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		resources := t["Resources"].(map[string]interface{})
+		for _, name := range names {
+			fmt.Printf("%s:  # %s\n", text.Yellow(name), resources[name].(map[string]interface{})["Type"])
+			for _, key := range missing[name] {
+				fmt.Printf("  - %s\n", text.Red(key))
+			}
+		}
+		fmt.Println()
+
+		panic(fmt.Errorf("%d resources are missing required tags", len(missing)))
+	},
+}
+
+// writeTaggedTemplate outputs a template that has had tags added to it
+func writeTaggedTemplate(fn string, t cfnTemplate.Template) {
+	output := format.Template(t, format.Options{})
+
+	// Verify the output is valid
+	err := parse.Verify(t, output)
+	if err != nil {
+		panic(err)
+	}
+
+	if writeTags {
+		err = ioutil.WriteFile(fn, []byte(output), 0644)
+		if err != nil {
+			panic(fmt.Errorf("Unable to write to '%s': %s", fn, err))
+		}
+	} else {
+		fmt.Println(output)
+	}
+}
+
+func init() {
+	tagCmd.Flags().StringSliceVar(&requiredTags, "require", []string{}, "Names of tags that every taggable resource must have. Use the format key1,key2.")
+	tagCmd.Flags().StringSliceVar(&addTags, "add", []string{}, "Add tags to every taggable resource. Use the format key1=value1,key2=value2.")
+	tagCmd.Flags().BoolVarP(&writeTags, "write", "w", false, "With --add, write the output back to the file rather than to stdout.")
+	Root.AddCommand(tagCmd)
+}
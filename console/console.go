@@ -18,6 +18,9 @@ var IsTTY bool
 // HasColour is true if your program is running a platform that supports ANSI colours
 var HasColour bool
 
+// ErrInterrupted is raised by Ask if the user presses Ctrl-C instead of answering
+var ErrInterrupted = errors.New("Interrupted")
+
 func init() {
 	IsTTY = termutil.Isatty(os.Stdout.Fd())
 	HasColour = runtime.GOOS != "windows"
@@ -56,8 +59,12 @@ func Ask(prompt string) string {
 		panic(fmt.Errorf("Unable to get user input: %s", err))
 	}
 
+	defer rl.Close()
+
 	answer, err := rl.Readline()
-	if err != nil {
+	if err == readline.ErrInterrupt {
+		panic(ErrInterrupted)
+	} else if err != nil {
 		panic(fmt.Errorf("Unable to get user input: %s", err))
 	}
 
@@ -250,6 +250,39 @@ __rain_handle_word()
     __rain_handle_word
 }
 
+_rain_apply()
+{
+    last_command="rain_apply"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--force")
+    flags+=("-f")
+    local_nonpersistent_flags+=("--force")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
 _rain_cat()
 {
     last_command="rain_cat"
@@ -333,12 +366,64 @@ _rain_deploy()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--disable-rollback")
+    local_nonpersistent_flags+=("--disable-rollback")
+    flags+=("--dry-run")
+    local_nonpersistent_flags+=("--dry-run")
+    flags+=("--file=")
+    two_word_flags+=("--file")
+    two_word_flags+=("-m")
+    local_nonpersistent_flags+=("--file=")
     flags+=("--force")
     flags+=("-f")
     local_nonpersistent_flags+=("--force")
     flags+=("--help")
     flags+=("-h")
     local_nonpersistent_flags+=("--help")
+    flags+=("--lint")
+    local_nonpersistent_flags+=("--lint")
+    flags+=("--monitoring-time=")
+    two_word_flags+=("--monitoring-time")
+    local_nonpersistent_flags+=("--monitoring-time=")
+    flags+=("--notification-arns=")
+    two_word_flags+=("--notification-arns")
+    local_nonpersistent_flags+=("--notification-arns=")
+    flags+=("--param=")
+    two_word_flags+=("--param")
+    local_nonpersistent_flags+=("--param=")
+    flags+=("--params=")
+    two_word_flags+=("--params")
+    local_nonpersistent_flags+=("--params=")
+    flags+=("--plan-out=")
+    two_word_flags+=("--plan-out")
+    local_nonpersistent_flags+=("--plan-out=")
+    flags+=("--provenance")
+    local_nonpersistent_flags+=("--provenance")
+    flags+=("--provenance-tags=")
+    two_word_flags+=("--provenance-tags")
+    local_nonpersistent_flags+=("--provenance-tags=")
+    flags+=("--regions=")
+    two_word_flags+=("--regions")
+    local_nonpersistent_flags+=("--regions=")
+    flags+=("--role-arn=")
+    two_word_flags+=("--role-arn")
+    local_nonpersistent_flags+=("--role-arn=")
+    flags+=("--rollback-alarms=")
+    two_word_flags+=("--rollback-alarms")
+    local_nonpersistent_flags+=("--rollback-alarms=")
+    flags+=("--skip-resources=")
+    two_word_flags+=("--skip-resources")
+    local_nonpersistent_flags+=("--skip-resources=")
+    flags+=("--stack-policy=")
+    two_word_flags+=("--stack-policy")
+    local_nonpersistent_flags+=("--stack-policy=")
+    flags+=("--strip-metadata")
+    local_nonpersistent_flags+=("--strip-metadata")
+    flags+=("--tags=")
+    two_word_flags+=("--tags")
+    local_nonpersistent_flags+=("--tags=")
+    flags+=("--termination-protection")
+    local_nonpersistent_flags+=("--termination-protection")
     flags+=("--debug")
     flags+=("--profile=")
     two_word_flags+=("--profile")
@@ -385,6 +470,42 @@ _rain_diff()
     noun_aliases=()
 }
 
+_rain_drift()
+{
+    last_command="rain_drift"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--json")
+    flags+=("-j")
+    local_nonpersistent_flags+=("--json")
+    flags+=("--resource=")
+    two_word_flags+=("--resource")
+    local_nonpersistent_flags+=("--resource=")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
 _rain_fmt()
 {
     last_command="rain_fmt"
@@ -427,6 +548,125 @@ _rain_fmt()
     noun_aliases=()
 }
 
+_rain_iam()
+{
+    last_command="rain_iam"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_import()
+{
+    last_command="rain_import"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--force")
+    flags+=("-f")
+    local_nonpersistent_flags+=("--force")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--param=")
+    two_word_flags+=("--param")
+    local_nonpersistent_flags+=("--param=")
+    flags+=("--params=")
+    two_word_flags+=("--params")
+    local_nonpersistent_flags+=("--params=")
+    flags+=("--resource=")
+    two_word_flags+=("--resource")
+    local_nonpersistent_flags+=("--resource=")
+    flags+=("--role-arn=")
+    two_word_flags+=("--role-arn")
+    local_nonpersistent_flags+=("--role-arn=")
+    flags+=("--tags=")
+    two_word_flags+=("--tags")
+    local_nonpersistent_flags+=("--tags=")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_lint()
+{
+    last_command="rain_lint"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--all")
+    flags+=("-a")
+    local_nonpersistent_flags+=("--all")
+    flags+=("--fix")
+    local_nonpersistent_flags+=("--fix")
+    flags+=("--force")
+    flags+=("-f")
+    local_nonpersistent_flags+=("--force")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--rules=")
+    two_word_flags+=("--rules")
+    local_nonpersistent_flags+=("--rules=")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
 _rain_logs()
 {
     last_command="rain_logs"
@@ -513,12 +753,273 @@ _rain_rm()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--disable-termination-protection")
+    local_nonpersistent_flags+=("--disable-termination-protection")
+    flags+=("--file=")
+    two_word_flags+=("--file")
+    two_word_flags+=("-m")
+    local_nonpersistent_flags+=("--file=")
+    flags+=("--force")
+    flags+=("-f")
+    local_nonpersistent_flags+=("--force")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--role-arn=")
+    two_word_flags+=("--role-arn")
+    local_nonpersistent_flags+=("--role-arn=")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_stackset_deploy()
+{
+    last_command="rain_stackset_deploy"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--accounts=")
+    two_word_flags+=("--accounts")
+    local_nonpersistent_flags+=("--accounts=")
+    flags+=("--admin-role-arn=")
+    two_word_flags+=("--admin-role-arn")
+    local_nonpersistent_flags+=("--admin-role-arn=")
+    flags+=("--execution-role-name=")
+    two_word_flags+=("--execution-role-name")
+    local_nonpersistent_flags+=("--execution-role-name=")
+    flags+=("--failure-tolerance=")
+    two_word_flags+=("--failure-tolerance")
+    local_nonpersistent_flags+=("--failure-tolerance=")
     flags+=("--force")
     flags+=("-f")
     local_nonpersistent_flags+=("--force")
     flags+=("--help")
     flags+=("-h")
     local_nonpersistent_flags+=("--help")
+    flags+=("--max-concurrent=")
+    two_word_flags+=("--max-concurrent")
+    local_nonpersistent_flags+=("--max-concurrent=")
+    flags+=("--param=")
+    two_word_flags+=("--param")
+    local_nonpersistent_flags+=("--param=")
+    flags+=("--params=")
+    two_word_flags+=("--params")
+    local_nonpersistent_flags+=("--params=")
+    flags+=("--regions=")
+    two_word_flags+=("--regions")
+    local_nonpersistent_flags+=("--regions=")
+    flags+=("--tags=")
+    two_word_flags+=("--tags")
+    local_nonpersistent_flags+=("--tags=")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_stackset_ls()
+{
+    last_command="rain_stackset_ls"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_stackset_rm()
+{
+    last_command="rain_stackset_rm"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--accounts=")
+    two_word_flags+=("--accounts")
+    local_nonpersistent_flags+=("--accounts=")
+    flags+=("--failure-tolerance=")
+    two_word_flags+=("--failure-tolerance")
+    local_nonpersistent_flags+=("--failure-tolerance=")
+    flags+=("--force")
+    flags+=("-f")
+    local_nonpersistent_flags+=("--force")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--max-concurrent=")
+    two_word_flags+=("--max-concurrent")
+    local_nonpersistent_flags+=("--max-concurrent=")
+    flags+=("--regions=")
+    two_word_flags+=("--regions")
+    local_nonpersistent_flags+=("--regions=")
+    flags+=("--retain-stacks")
+    local_nonpersistent_flags+=("--retain-stacks")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_stackset_watch()
+{
+    last_command="rain_stackset_watch"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_stackset()
+{
+    last_command="rain_stackset"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("deploy")
+    commands+=("ls")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("list")
+        aliashash["list"]="ls"
+    fi
+    commands+=("rm")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("del")
+        aliashash["del"]="rm"
+        command_aliases+=("delete")
+        aliashash["delete"]="rm"
+        command_aliases+=("remove")
+        aliashash["remove"]="rm"
+    fi
+    commands+=("watch")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--debug")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    two_word_flags+=("-p")
+    flags+=("--region=")
+    two_word_flags+=("--region")
+    two_word_flags+=("-r")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_rain_tag()
+{
+    last_command="rain_tag"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--add=")
+    two_word_flags+=("--add")
+    local_nonpersistent_flags+=("--add=")
+    flags+=("--help")
+    flags+=("-h")
+    local_nonpersistent_flags+=("--help")
+    flags+=("--require=")
+    two_word_flags+=("--require")
+    local_nonpersistent_flags+=("--require=")
+    flags+=("--write")
+    flags+=("-w")
+    local_nonpersistent_flags+=("--write")
     flags+=("--debug")
     flags+=("--profile=")
     two_word_flags+=("--profile")
@@ -635,15 +1136,20 @@ _rain_root_command()
     command_aliases=()
 
     commands=()
+    commands+=("apply")
     commands+=("cat")
     commands+=("check")
     commands+=("deploy")
     commands+=("diff")
+    commands+=("drift")
     commands+=("fmt")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("format")
         aliashash["format"]="fmt"
     fi
+    commands+=("iam")
+    commands+=("import")
+    commands+=("lint")
     commands+=("logs")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("log")
@@ -663,6 +1169,8 @@ _rain_root_command()
         command_aliases+=("remove")
         aliashash["remove"]="rm"
     fi
+    commands+=("stackset")
+    commands+=("tag")
     commands+=("tree")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("graph")
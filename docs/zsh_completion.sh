@@ -2,12 +2,23 @@
 
 _arguments \
   '1: :->level1' \
-  '2: :_files'
+  '2: :->level2' \
+  '3: :_files'
 case $state in
   level1)
     case $words[1] in
       rain)
-        _arguments '1: :(cat check deploy diff fmt help logs ls rm tree version watch)'
+        _arguments '1: :(apply cat check deploy diff drift fmt help iam import lint logs ls rm stackset tag tree version watch)'
+      ;;
+      *)
+        _arguments '*: :_files'
+      ;;
+    esac
+  ;;
+  level2)
+    case $words[2] in
+      stackset)
+        _arguments '2: :(deploy help ls rm watch)'
       ;;
       *)
         _arguments '*: :_files'
@@ -29,10 +29,10 @@ func main() {
 
 		if r := recover(); r != nil {
 			fmt.Println(text.Red(fmt.Sprint(r)))
-			os.Exit(1)
+			os.Exit(cmd.ErrorExitCode())
 		}
 
-		os.Exit(0)
+		os.Exit(cmd.ExitCode)
 	}()
 
 	cmd.Execute()